@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// prepareReview applies the configured review policy to review and moves
+// its comments onto the working tree at dir, the directory of p or a
+// worktree of it, embedding source context.
+func prepareReview(ctx context.Context, p *Project, dir string, review *FormattedReview) (*FormattedReview, error) {
+	review = p.Config().Review.Policy.Apply(review)
+	if len(review.Comments) == 0 {
+		return nil, fmt.Errorf("no review comments left to send after applying the review policy")
+	}
+	mapOldSide(ctx, dir, review)
+	if n := p.Config().Review.ContextLines; n > 0 {
+		embedContext(ctx, dir, review, n)
+	}
+	return review, nil
+}
+
+// buildPrompt renders review into the prompt sent to the non-interactive agent
+// using the configured review policy, format and prompt template of p.
+func buildPrompt(ctx context.Context, p *Project, review *FormattedReview) (string, error) {
+	review, err := prepareReview(ctx, p, p.path, review)
+	if err != nil {
+		return "", err
+	}
+	f, err := projectFormatter(p)
+	if err != nil {
+		return "", err
+	}
+	return formatReview(f, review)
+}
+
+// runAgent runs the non-interactive agent in dir, the directory of p or a
+// worktree of it, with prompt as its last argument.
+func runAgent(ctx context.Context, p *Project, dir string, agent AgentSection, prompt string) ([]byte, error) {
+	if agent.Agent == "" {
+		return nil, fmt.Errorf("no non-interactive agent configured")
+	}
+	args := append(slices.Clone(agent.Args), prompt)
+	started := time.Now()
+	output, err := executeEnv(ctx, dir, p.Config().environ(), agent.Agent, args...)
+	// A log that cannot be written must not fail the run.
+	_ = writeAgentLog(p, dir, agent, prompt, output, err, started)
+	if err != nil {
+		return output, fmt.Errorf("run %s: %w", agent.Agent, err)
+	}
+	return output, nil
+}
+
+// fixReview runs the non-interactive agent on a stored review. In parallel
+// mode the review is split into groups fixed by concurrent agent runs.
+// Otherwise a review exceeding the prompt budget is sent in parts, each
+// reported to progress before it is sent; see runReview.
+func fixReview(ctx context.Context, p *Project, r *ReviewRecord, progress partProgress) ([]byte, error) {
+	c := p.Config()
+	if pc := c.Review.Parallel; pc.Enabled {
+		groups := splitReview(c.Review.Policy.Apply(r.Review()), pc.GroupBy)
+		if len(groups) > 1 {
+			return parallelReport(runParallel(ctx, p, c.NonInteractive, groups, pc.MaxConcurrency))
+		}
+	}
+	if limit := c.Review.Budget.Limit(); limit > 0 && len(r.Prompt) > limit {
+		return runReview(ctx, p, p.path, c.NonInteractive, r.Review(), progress)
+	}
+	return runAgent(ctx, p, p.path, c.NonInteractive, r.Prompt)
+}
+
+// finishReview records the agent's response to r in the project's review history.
+func finishReview(p *Project, r *ReviewRecord, output []byte, err error) error {
+	r.Agent = p.Config().NonInteractive.Agent
+	r.Response = string(output)
+	r.Error = ""
+	if err != nil {
+		r.Error = err.Error()
+	}
+	r.SentAt = time.Now()
+	return p.SaveReview(r)
+}
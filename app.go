@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -19,18 +21,28 @@ import (
 
 type cmdFinishedMsg struct{ err error }
 
-func interactive(sess ssh.Session, dir string, cmd string, args ...string) tea.Cmd {
+func interactive(sess ssh.Session, dir string, env []string, cmd string, args ...string) tea.Cmd {
 	callback := func(err error) tea.Msg {
 		return cmdFinishedMsg{err: err}
 	}
+	return execInteractive(sess, dir, env, callback, cmd, args...)
+}
+
+func execInteractive(sess ssh.Session, dir string, env []string, callback tea.ExecCallback, cmd string, args ...string) tea.Cmd {
 	if sess != nil {
 		wishCmd := wish.Command(sess, cmd, args...)
 		if dir != "" {
 			wishCmd.SetDir(dir)
 		}
+		if len(env) > 0 {
+			wishCmd.SetEnv(append(wishCmd.Environ(), env...))
+		}
 		return tea.Exec(wishCmd, callback)
 	}
 	c := command(context.Background(), dir, cmd, args...)
+	if len(env) > 0 {
+		c.Env = append(os.Environ(), env...)
+	}
 	return tea.ExecProcess(c, callback)
 }
 
@@ -52,6 +64,28 @@ func checkoutForm(repoName string) *huh.Form {
 	)
 }
 
+func diffBaseForm(repoName string, base *string) *huh.Form {
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().Key("base").Title("base").Value(base).Placeholder("e.g. HEAD, main").Validate(huh.ValidateNotEmpty()),
+		).Title(fmt.Sprintf("%s – review changes against", repoName)),
+	)
+}
+
+func agentProfileForm(repoName string, c *AgentConfig) *huh.Form {
+	var options []huh.Option[string]
+	for _, name := range c.profileNames() {
+		a := c.Profiles[name]
+		label := fmt.Sprintf("%s (%s)", name, strings.Join(append([]string{a.Agent}, a.Args...), " "))
+		options = append(options, huh.NewOption(label, name))
+	}
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().Key("profile").Title("agent").Options(options...),
+		).Title(fmt.Sprintf("%s – start interactive agent", repoName)),
+	)
+}
+
 func deleteConfirmForm(kind, name string) *huh.Form {
 	return huh.NewForm(
 		huh.NewGroup(
@@ -64,6 +98,19 @@ func deleteConfirmForm(kind, name string) *huh.Form {
 	)
 }
 
+func approveCommandsForm(repoName string, commands []string) *huh.Form {
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Key("confirm").
+				Title("Run the commands set by " + projectConfigName + " of " + repoName + "?").
+				Description(strings.Join(commands, "\n")).
+				Affirmative("Yes").
+				Negative("No"),
+		),
+	)
+}
+
 type state uint
 
 const (
@@ -71,6 +118,17 @@ const (
 	newRepoState
 	checkoutState
 	deleteProjectState
+	outputState
+	historyState
+	diffBaseState
+	diffState
+	agentProfileState
+	jobsState
+	logsState
+	checksState
+	checkpointsState
+	rollbackState
+	approveCommandsState
 )
 
 type model struct {
@@ -82,26 +140,64 @@ type model struct {
 	state     state
 	spinner   spinner.Model
 	loading   bool
+	status    string
+	width     int
+	height    int
 
 	projectList     *ProjectList
 	selectedProject *Project
+	output          *OutputView
+	outputReturn    state
+	history         *ReviewHistory
+	diff            *DiffView
+	logList         *LogList
+	checks          *ChecksView
+	checkpoints     *CheckpointList
+	// checkpoint is the checkpoint to roll back to once confirmed.
+	checkpoint *Checkpoint
+	// pendingAction is the action on selectedProject to run once its
+	// commands are approved.
+	pendingAction ProjectAction
+
+	jobs      *JobQueue
+	jobEvents <-chan JobEvent
+	jobList   *JobList
+	// ownJobs holds the jobs queued from this session; notice reports on them.
+	ownJobs     map[string]bool
+	notice      string
+	noticeStyle lipgloss.Style
 }
 
-func NewModel(workspace string, sess ssh.Session, renderer *lipgloss.Renderer) tea.Model {
+type jobEventMsg JobEvent
+
+func NewModel(workspace string, sess ssh.Session, renderer *lipgloss.Renderer, jobs *JobQueue) tea.Model {
 	s := spinner.New()
+	events, unsubscribe := jobs.Subscribe()
+	if sess != nil {
+		go func() {
+			<-sess.Context().Done()
+			unsubscribe()
+		}()
+	}
 	return &model{
-		workspace: workspace,
-		sess:      sess,
-		errStyle:  renderer.NewStyle().Foreground(lipgloss.Color("3")),
-		spinner:   s,
-		loading:   true,
+		workspace:   workspace,
+		sess:        sess,
+		jobs:        jobs,
+		jobEvents:   events,
+		ownJobs:     map[string]bool{},
+		noticeStyle: renderer.NewStyle().Foreground(lipgloss.Color("241")),
+		errStyle:    renderer.NewStyle().Foreground(lipgloss.Color("3")),
+		spinner:     s,
+		loading:     true,
+		width:       80,
+		height:      20,
 	}
 }
 
-func NewTeaHandler(workspace string) bubbletea.Handler {
+func NewTeaHandler(workspace string, jobs *JobQueue) bubbletea.Handler {
 	return func(s ssh.Session) (tea.Model, []tea.ProgramOption) {
 		renderer := bubbletea.MakeRenderer(s)
-		m := NewModel(workspace, s, renderer)
+		m := NewModel(workspace, s, renderer, jobs)
 		return m, []tea.ProgramOption{tea.WithAltScreen()}
 	}
 }
@@ -121,7 +217,343 @@ func (m *model) startLoadProjects() tea.Cmd {
 	return tea.Batch(m.spinner.Tick, m.loadProjects)
 }
 
-func (m *model) Init() tea.Cmd { return tea.Batch(m.spinner.Tick, m.loadProjects) }
+func (m *model) startTask(status string, cmd tea.Cmd) tea.Cmd {
+	m.loading = true
+	m.status = status
+	return tea.Batch(m.spinner.Tick, cmd)
+}
+
+func (m *model) showOutput(title, content string) {
+	if m.state != outputState {
+		m.outputReturn = m.state
+	}
+	m.output = NewOutputView(title, content, m.width, m.height)
+	m.state = outputState
+}
+
+func (m *model) handleReviewCaptured(msg reviewCapturedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = fmt.Errorf("review: %w", msg.err)
+		return m, nil
+	}
+	return m, m.recordReview(msg.project, msg.review, msg.previous)
+}
+
+// recordReview stores review in the history of p and sends it to the agent.
+func (m *model) recordReview(p *Project, review *FormattedReview, prev *ReviewRecord) tea.Cmd {
+	if len(review.Comments) == 0 {
+		m.err = fmt.Errorf("review of %s has no comments", p.Title())
+		return nil
+	}
+	m.err = nil
+	record, err := newReviewRecord(context.Background(), p, review, prev)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	if err := p.SaveReview(record); err != nil {
+		m.err = err
+		return nil
+	}
+	return m.sendReview(p, record)
+}
+
+// startDiffReview asks for the base to review p against, defaulting to the
+// commit of the previous round.
+func (m *model) startDiffReview(p *Project) tea.Cmd {
+	base := "HEAD"
+	if prev, err := previousRound(p, p.branch); err == nil && prev != nil && prev.CommitSha != "" {
+		base = prev.CommitSha
+	}
+	m.selectedProject = p
+	return m.setForm(diffBaseForm(p.Title(), &base), diffBaseState)
+}
+
+func (m *model) handleDiffLoaded(msg diffLoadedMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	m.status = ""
+	if msg.err != nil {
+		m.err = fmt.Errorf("diff review: %w", msg.err)
+		return m, nil
+	}
+	m.err = nil
+	m.diff = NewDiffView(msg.project, msg.previous, msg.base, msg.files, m.width, m.height)
+	m.state = diffState
+	return m, nil
+}
+
+func (m *model) handleGitHubImported(msg githubImportedMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	m.status = ""
+	if msg.err != nil {
+		m.err = fmt.Errorf("import pull request comments: %w", msg.err)
+		return m, nil
+	}
+	return m, m.recordReview(msg.project, msg.review, nil)
+}
+
+// sendReview queues a job running the non-interactive agent on r.
+func (m *model) sendReview(p *Project, r *ReviewRecord) tea.Cmd {
+	job, err := m.jobs.Enqueue(p, r)
+	if err != nil {
+		m.err = fmt.Errorf("queue agent job: %w", err)
+		return nil
+	}
+	m.ownJobs[job.ID] = true
+	m.notice = fmt.Sprintf("Queued %d review comments on %s for %s (J shows jobs)", len(r.Comments), p.Title(), job.Agent)
+	return nil
+}
+
+func (m *model) waitForJobEvent() tea.Msg {
+	ev, ok := <-m.jobEvents
+	if !ok {
+		return nil
+	}
+	return jobEventMsg(ev)
+}
+
+func (m *model) handleJobEvent(msg jobEventMsg) (tea.Model, tea.Cmd) {
+	p, job := msg.Project, msg.Job
+	var cmd tea.Cmd
+	if m.jobList != nil && m.jobList.project.path == p.path {
+		cmd = m.jobList.SetJob(job)
+	}
+	if m.ownJobs[job.ID] {
+		switch {
+		case job.Status == JobRunning && job.Progress != "":
+			m.notice = fmt.Sprintf("%s is fixing %s (%s)", job.Agent, p.Title(), job.Progress)
+		case job.Status == JobRunning:
+			m.notice = fmt.Sprintf("%s is fixing %s", job.Agent, p.Title())
+		case job.Status.Done():
+			delete(m.ownJobs, job.ID)
+			m.notice = fmt.Sprintf("Job on %s %s (J shows jobs)", p.Title(), job.Status)
+			if job.TCR != nil {
+				m.notice = fmt.Sprintf("Job on %s %s: %s (J shows jobs)", p.Title(), job.Status, job.TCR.Summary())
+			}
+			if m.state == historyState && m.history.project.path == p.path {
+				// Reload so the history shows the new response.
+				m.showHistory(m.history.project)
+			}
+		}
+	}
+	return m, tea.Batch(cmd, m.waitForJobEvent)
+}
+
+func (m *model) showJobs(p *Project) {
+	jobs, err := m.jobs.Jobs(p)
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.jobList = NewJobList(p, jobs, m.width, m.height)
+	m.state = jobsState
+}
+
+func (m *model) handleJobsSelected(msg jobsSelectedMsg) (tea.Model, tea.Cmd) {
+	p := m.jobList.project
+	switch msg.action {
+	case JobsActionView:
+		m.showOutput(fmt.Sprintf("%s – job %s", p.Title(), msg.job.ID), msg.job.Details())
+	case JobsActionCancel:
+		if err := m.jobs.Cancel(msg.job.ID); err != nil {
+			m.err = err
+		}
+	case JobsActionRetry:
+		job, err := m.jobs.Retry(p, msg.job)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.ownJobs[job.ID] = true
+		return m, m.jobList.SetJob(*job)
+	case JobsActionBack:
+		m.jobList = nil
+		m.state = mainState
+		return m, m.startLoadProjects()
+	}
+	return m, nil
+}
+
+func (m *model) showChecks(p *Project) {
+	results, err := p.Checks()
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.checks = NewChecksView(p, results, m.width, m.height)
+	m.state = checksState
+}
+
+func (m *model) handleChecksFinished(msg checksFinishedMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	m.status = ""
+	m.err = msg.err
+	if len(msg.results) > 0 {
+		m.notice = fmt.Sprintf("%s: %s (T shows output)", msg.project.Title(), checksSummary(msg.results))
+	}
+	if m.state == checksState && m.checks.project.path == msg.project.path {
+		results, err := msg.project.Checks()
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		return m, m.checks.SetResults(results)
+	}
+	if m.state == mainState {
+		// Reload so the badges show the new results.
+		return m, m.startLoadProjects()
+	}
+	return m, nil
+}
+
+func (m *model) handleChecksSelected(msg checksSelectedMsg) (tea.Model, tea.Cmd) {
+	p := m.checks.project
+	switch msg.action {
+	case ChecksActionView:
+		m.showOutput(fmt.Sprintf("%s – %s", p.Title(), msg.result.Title()), msg.result.Details())
+	case ChecksActionRerun:
+		return m, m.startTask(fmt.Sprintf("Running %s of %s...", msg.result.Kind, p.Title()), runChecksCmd(p, msg.result.Kind))
+	case ChecksActionSendFailures:
+		review := failureReview(p.path, m.checks.Results(), nil)
+		if review == nil {
+			m.err = fmt.Errorf("no failed checks of %s to send", p.Title())
+			return m, nil
+		}
+		return m, m.recordReview(p, review, nil)
+	case ChecksActionBack:
+		m.checks = nil
+		m.state = mainState
+		return m, m.startLoadProjects()
+	}
+	return m, nil
+}
+
+func (m *model) showCheckpoints(p *Project) {
+	cps, err := p.Checkpoints(context.Background())
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.checkpoints = NewCheckpointList(p, cps, m.width, m.height)
+	m.state = checkpointsState
+}
+
+// checkIdle returns an error if agent jobs of p are running or queued, which
+// actions changing or reading its working tree would interfere with.
+func (m *model) checkIdle(p *Project) error {
+	if m.jobs.Busy(p.path) {
+		return fmt.Errorf("%s has agent jobs running; wait for them or cancel them first", p.Title())
+	}
+	return nil
+}
+
+// confirmRollback asks before restoring p to cp.
+func (m *model) confirmRollback(p *Project, cp *Checkpoint) tea.Cmd {
+	if err := m.checkIdle(p); err != nil {
+		m.err = err
+		m.state = mainState
+		return m.startLoadProjects()
+	}
+	m.selectedProject = p
+	m.checkpoint = cp
+	return m.setForm(deleteConfirmForm("changes since checkpoint", cp.Title()+" of "+p.Title()), rollbackState)
+}
+
+func (m *model) handleCheckpointsSelected(msg checkpointsSelectedMsg) (tea.Model, tea.Cmd) {
+	p := m.checkpoints.project
+	m.checkpoints = nil
+	switch msg.action {
+	case CheckpointsActionRollback:
+		return m, m.confirmRollback(p, msg.checkpoint)
+	case CheckpointsActionBack:
+		m.state = mainState
+		return m, m.startLoadProjects()
+	}
+	return m, nil
+}
+
+func (m *model) showLogs(p *Project) {
+	logs, err := p.Logs()
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.logList = NewLogList(p, logs, m.width, m.height)
+	m.state = logsState
+}
+
+func (m *model) handleLogsSelected(msg logsSelectedMsg) (tea.Model, tea.Cmd) {
+	switch msg.action {
+	case LogsActionView:
+		content, err := msg.log.Content()
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.showOutput(fmt.Sprintf("%s – %s log %s", m.logList.project.Title(), msg.log.Kind, msg.log.Time.Local().Format(time.DateTime)), content)
+	case LogsActionBack:
+		m.logList = nil
+		m.state = mainState
+		return m, m.startLoadProjects()
+	}
+	return m, nil
+}
+
+// interact starts an interactive session of agent in p, recording a
+// transcript in the logs of p after taking a checkpoint.
+func (m *model) interact(p *Project, agent AgentSection) tea.Cmd {
+	if err := m.checkIdle(p); err != nil {
+		m.err = err
+		return nil
+	}
+	if err := checkpointBefore(context.Background(), p, "interactive "+agent.Agent); err != nil {
+		m.err = err
+		return nil
+	}
+	cmd, args := transcriptCommand(p, agent.Agent, agent.Args)
+	return interactive(m.sess, p.path, p.Config().environ(), cmd, args...)
+}
+
+func (m *model) showHistory(p *Project) {
+	records, err := p.Reviews()
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.history = NewReviewHistory(p, records, m.width, m.height)
+	m.state = historyState
+}
+
+func (m *model) handleHistorySelected(msg historySelectedMsg) (tea.Model, tea.Cmd) {
+	p := m.history.project
+	switch msg.action {
+	case HistoryActionView:
+		m.showOutput(fmt.Sprintf("%s – review %s", p.Title(), msg.record.Title()), msg.record.Details())
+	case HistoryActionSend:
+		return m, m.sendReview(p, msg.record)
+	case HistoryActionPublish:
+		return m, m.startTask(fmt.Sprintf("Publishing review %s to GitHub...", shortSha(msg.record.CommitSha)), publishReviewCmd(p, msg.record))
+	case HistoryActionBack:
+		m.history = nil
+		m.state = mainState
+		return m, m.startLoadProjects()
+	}
+	return m, nil
+}
+
+func (m *model) handleGitHubPublished(msg githubPublishedMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	m.status = ""
+	if msg.err != nil {
+		m.err = fmt.Errorf("publish review: %w", msg.err)
+		return m, nil
+	}
+	m.err = nil
+	m.showOutput(msg.project.Title()+" – GitHub review", "Published review: "+msg.review.HTMLURL+"\n")
+	return m, nil
+}
+
+func (m *model) Init() tea.Cmd { return tea.Batch(m.spinner.Tick, m.loadProjects, m.waitForJobEvent) }
 
 func (m *model) setForm(form *huh.Form, s state) tea.Cmd {
 	m.form = form
@@ -155,18 +587,70 @@ func (m *model) handleFormDone() (tea.Model, tea.Cmd) {
 			m.selectedProject = nil
 			m.setForm(nil, mainState)
 			if p != nil {
-				if err := p.AddWorktree(context.Background(), name); err != nil {
+				if err := m.checkIdle(p); err != nil {
+					m.err = err
+				} else if err := p.AddWorktree(context.Background(), name); err != nil {
+					m.err = err
+				}
+			}
+			return m, m.startLoadProjects()
+		case agentProfileState:
+			name := m.form.Get("profile").(string)
+			p := m.selectedProject
+			m.selectedProject = nil
+			m.setForm(nil, mainState)
+			agent, err := p.Config().profile(name)
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			return m, m.interact(p, agent)
+		case diffBaseState:
+			base := m.form.Get("base").(string)
+			p := m.selectedProject
+			m.selectedProject = nil
+			m.setForm(nil, mainState)
+			return m, m.startTask(fmt.Sprintf("Loading changes of %s against %s...", p.Title(), base), loadDiffCmd(p, base))
+		case rollbackState:
+			confirmed := m.form.Get("confirm").(bool)
+			p, cp := m.selectedProject, m.checkpoint
+			m.selectedProject, m.checkpoint = nil, nil
+			m.setForm(nil, mainState)
+			if confirmed {
+				if err := m.checkIdle(p); err != nil {
 					m.err = err
+				} else if err := restoreCheckpoint(context.Background(), p, cp); err != nil {
+					m.err = err
+				} else {
+					m.notice = fmt.Sprintf("Rolled back %s to %s (C lists checkpoints)", p.Title(), cp.Title())
 				}
 			}
 			return m, m.startLoadProjects()
+		case approveCommandsState:
+			confirmed := m.form.Get("confirm").(bool)
+			p, action := m.selectedProject, m.pendingAction
+			m.selectedProject, m.pendingAction = nil, ProjectActionNone
+			m.setForm(nil, mainState)
+			if !confirmed {
+				return m, m.startLoadProjects()
+			}
+			if err := p.approveCommands(p.unapprovedCommands()); err != nil {
+				m.err = err
+				return m, nil
+			}
+			return m.Update(projectSelectedMsg{action: action, project: p})
 		case deleteProjectState:
 			confirmed := m.form.Get("confirm").(bool)
-			projectPath := m.selectedProject.path
+			p := m.selectedProject
 			m.selectedProject = nil
 			m.setForm(nil, mainState)
 			if confirmed {
-				if err := os.RemoveAll(projectPath); err != nil {
+				// A worktree is removed with git, keeping its branch.
+				if err := m.checkIdle(p); err != nil {
+					m.err = err
+				} else if p.root != "" {
+					m.err = removeWorktree(context.Background(), p.root, p.path)
+				} else if err := os.RemoveAll(p.path); err != nil {
 					m.err = err
 				}
 			}
@@ -198,6 +682,25 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 	}
 
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		m.width, m.height = msg.Width, msg.Height
+	}
+
+	switch msg := msg.(type) {
+	case reviewCapturedMsg:
+		return m.handleReviewCaptured(msg)
+	case jobEventMsg:
+		return m.handleJobEvent(msg)
+	case githubPublishedMsg:
+		return m.handleGitHubPublished(msg)
+	case githubImportedMsg:
+		return m.handleGitHubImported(msg)
+	case diffLoadedMsg:
+		return m.handleDiffLoaded(msg)
+	case checksFinishedMsg:
+		return m.handleChecksFinished(msg)
+	}
+
 	if msg, ok := msg.(cmdFinishedMsg); ok && msg.err != nil {
 		m.err = msg.err
 		m.form = nil
@@ -207,28 +710,171 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch m.state {
-	case newRepoState, checkoutState, deleteProjectState:
+	case newRepoState, checkoutState, deleteProjectState, diffBaseState, agentProfileState, rollbackState, approveCommandsState:
 		return m.formUpdate(msg)
+	case outputState:
+		if _, ok := msg.(outputClosedMsg); ok {
+			m.output = nil
+			m.state = m.outputReturn
+			return m, nil
+		}
+		mdl, cmd := m.output.Update(msg)
+		if o, ok := mdl.(*OutputView); ok {
+			m.output = o
+		}
+		return m, cmd
+	case jobsState:
+		if msg, ok := msg.(jobsSelectedMsg); ok {
+			return m.handleJobsSelected(msg)
+		}
+		mdl, cmd := m.jobList.Update(msg)
+		if l, ok := mdl.(*JobList); ok {
+			m.jobList = l
+		}
+		return m, cmd
+	case checkpointsState:
+		if msg, ok := msg.(checkpointsSelectedMsg); ok {
+			return m.handleCheckpointsSelected(msg)
+		}
+		mdl, cmd := m.checkpoints.Update(msg)
+		if l, ok := mdl.(*CheckpointList); ok {
+			m.checkpoints = l
+		}
+		return m, cmd
+	case checksState:
+		if msg, ok := msg.(checksSelectedMsg); ok {
+			return m.handleChecksSelected(msg)
+		}
+		mdl, cmd := m.checks.Update(msg)
+		if v, ok := mdl.(*ChecksView); ok {
+			m.checks = v
+		}
+		return m, cmd
+	case logsState:
+		if msg, ok := msg.(logsSelectedMsg); ok {
+			return m.handleLogsSelected(msg)
+		}
+		mdl, cmd := m.logList.Update(msg)
+		if l, ok := mdl.(*LogList); ok {
+			m.logList = l
+		}
+		return m, cmd
+	case historyState:
+		if msg, ok := msg.(historySelectedMsg); ok {
+			return m.handleHistorySelected(msg)
+		}
+		mdl, cmd := m.history.Update(msg)
+		if h, ok := mdl.(*ReviewHistory); ok {
+			m.history = h
+		}
+		return m, cmd
+	case diffState:
+		switch msg := msg.(type) {
+		case diffReviewDoneMsg:
+			m.diff = nil
+			m.state = mainState
+			return m, m.recordReview(msg.project, msg.review, msg.previous)
+		case diffReviewClosedMsg:
+			m.diff = nil
+			m.state = mainState
+			return m, nil
+		}
+		mdl, cmd := m.diff.Update(msg)
+		if d, ok := mdl.(*DiffView); ok {
+			m.diff = d
+		}
+		return m, cmd
 	default: // mainState
 		switch msg := msg.(type) {
 		case projectsLoadedMsg:
-			m.loading = false
+			m.loading = m.status != ""
 			if msg.err != nil {
 				m.err = msg.err
 			} else {
 				m.err = nil
 			}
-			m.projectList = NewProjectList(msg.projects, 80, 20)
+			m.projectList = NewProjectList(msg.projects, m.width, m.height)
 			if len(msg.projects) == 0 && msg.err == nil {
 				return m, m.setForm(cloneForm(), newRepoState)
 			}
 			return m, nil
 		case projectSelectedMsg:
+			switch msg.action {
+			case ProjectActionCheckout, ProjectActionClone, ProjectActionDelete, ProjectActionQuit:
+			default:
+				// Acting on a project needs its configuration.
+				if err := msg.project.checkConfig(); err != nil {
+					m.err = err
+					return m, nil
+				}
+				// Commands set by the repository run only once approved.
+				if commands := msg.project.unapprovedCommands(); len(commands) > 0 {
+					m.selectedProject, m.pendingAction = msg.project, msg.action
+					return m, m.setForm(approveCommandsForm(msg.project.Title(), commands), approveCommandsState)
+				}
+			}
+			switch msg.action {
+			case ProjectActionReview, ProjectActionDiffReview, ProjectActionRunChecks, ProjectActionRollback,
+				ProjectActionInteract, ProjectActionPickAgent, ProjectActionCheckout, ProjectActionDelete:
+				// These switch, change or read the tree the jobs are working in.
+				if err := m.checkIdle(msg.project); err != nil {
+					m.err = err
+					return m, nil
+				}
+			}
 			switch msg.action {
 			case ProjectActionReview:
-				return m, interactive(m.sess, msg.project.path, "tuicr", "--stdout")
+				return m, captureReview(m.sess, msg.project)
+			case ProjectActionDiffReview:
+				return m, m.startDiffReview(msg.project)
+			case ProjectActionHistory:
+				m.showHistory(msg.project)
+				return m, nil
+			case ProjectActionJobs:
+				m.showJobs(msg.project)
+				return m, nil
+			case ProjectActionLogs:
+				m.showLogs(msg.project)
+				return m, nil
+			case ProjectActionRunChecks:
+				return m, m.startTask(fmt.Sprintf("Running checks of %s...", msg.project.Title()), runChecksCmd(msg.project))
+			case ProjectActionChecks:
+				m.showChecks(msg.project)
+				return m, nil
+			case ProjectActionRollback:
+				ctx := context.Background()
+				branch, err := headRef(ctx, msg.project.path)
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				cps, err := listCheckpoints(ctx, msg.project.path)
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				cp := lastAgentCheckpoint(cps, branch)
+				if cp == nil {
+					m.err = fmt.Errorf("no checkpoints of %s on %s", msg.project.Title(), branch)
+					return m, nil
+				}
+				return m, m.confirmRollback(msg.project, cp)
+			case ProjectActionCheckpoints:
+				m.showCheckpoints(msg.project)
+				return m, nil
+			case ProjectActionImport:
+				status := fmt.Sprintf("Importing pull request comments for %s...", msg.project.Title())
+				return m, m.startTask(status, importPullCommentsCmd(msg.project))
 			case ProjectActionInteract:
-				return m, interactive(m.sess, msg.project.path, cfg.Interactive.Agent, cfg.Interactive.Args...)
+				return m, m.interact(msg.project, msg.project.Config().Interactive)
+			case ProjectActionPickAgent:
+				c := msg.project.Config()
+				if len(c.Profiles) == 0 {
+					m.err = fmt.Errorf("no agent profiles configured")
+					return m, nil
+				}
+				m.selectedProject = msg.project
+				return m, m.setForm(agentProfileForm(msg.project.Title(), c), agentProfileState)
 			case ProjectActionCheckout:
 				m.selectedProject = msg.project
 				return m, m.setForm(checkoutForm(msg.project.Title()), checkoutState)
@@ -236,7 +882,11 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, m.setForm(cloneForm(), newRepoState)
 			case ProjectActionDelete:
 				m.selectedProject = msg.project
-				return m, m.setForm(deleteConfirmForm("project", msg.project.Title()), deleteProjectState)
+				kind := "project"
+				if msg.project.root != "" {
+					kind = "worktree"
+				}
+				return m, m.setForm(deleteConfirmForm(kind, msg.project.Title()), deleteProjectState)
 			case ProjectActionQuit:
 				return m, tea.Quit
 			}
@@ -257,20 +907,50 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// withStatus prefixes a list view with the running task, the last error and
+// the state of the background jobs.
+func (m *model) withStatus(view string) string {
+	notice := m.notice
+	if queued, running := m.jobs.Counts(); queued+running > 0 {
+		notice = strings.TrimPrefix(notice+fmt.Sprintf(" · jobs: %d running, %d queued", running, queued), " · ")
+	}
+	if notice != "" {
+		view = m.noticeStyle.Render(notice) + "\n\n" + view
+	}
+	if m.err != nil {
+		view = m.errStyle.Render(m.err.Error()+"\n\n") + view
+	}
+	if m.loading && m.status != "" {
+		view = m.spinner.View() + " " + m.status + "\n\n" + view
+	}
+	return view
+}
+
 func (m *model) View() string {
 	switch m.state {
-	case newRepoState, checkoutState, deleteProjectState:
+	case newRepoState, checkoutState, deleteProjectState, diffBaseState, agentProfileState, rollbackState, approveCommandsState:
 		return m.form.View()
+	case outputState:
+		return m.output.View()
+	case historyState:
+		return m.withStatus(m.history.View())
+	case jobsState:
+		return m.withStatus(m.jobList.View())
+	case logsState:
+		return m.withStatus(m.logList.View())
+	case checksState:
+		return m.withStatus(m.checks.View())
+	case checkpointsState:
+		return m.withStatus(m.checkpoints.View())
+	case diffState:
+		return m.diff.View()
 	}
-	if m.err != nil && m.projectList != nil {
-		return m.errStyle.Render(m.err.Error()+"\n\n") + m.projectList.View()
+	if m.projectList != nil {
+		return m.withStatus(m.projectList.View())
 	}
 	if m.err != nil {
 		return m.errStyle.Render(m.err.Error() + "\n")
 	}
-	if m.projectList != nil {
-		return m.projectList.View()
-	}
 	if m.loading {
 		return m.spinner.View() + " Loading projects..."
 	}
@@ -281,7 +961,10 @@ func bootstrapWorkspace(dir string) error {
 	return os.MkdirAll(dir, 0755)
 }
 
-type appCmd struct{ workspace string }
+type appCmd struct {
+	workspace string
+	format    string
+}
 
 func (*appCmd) Name() string     { return "start" }
 func (*appCmd) Synopsis() string { return "start local process" }
@@ -290,12 +973,17 @@ func (a *appCmd) SetFlags(f *flag.FlagSet) {
 	home, _ := os.UserHomeDir()
 	ws := filepath.Join(home, ".local", "share", "tcr")
 	f.StringVar(&a.workspace, "workspace", ws, "dir for git worktree")
+	f.StringVar(&a.format, "format", "", "review prompt format (default from config)")
 }
 func (a *appCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...any) subcommands.ExitStatus {
+	if err := setReviewFormat(a.format); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return subcommands.ExitUsageError
+	}
 	if err := bootstrapWorkspace(a.workspace); err != nil {
 		return subcommands.ExitFailure
 	}
-	m := NewModel(a.workspace, nil, lipgloss.DefaultRenderer())
+	m := NewModel(a.workspace, nil, lipgloss.DefaultRenderer(), NewJobQueue())
 	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
 		return subcommands.ExitFailure
 	}
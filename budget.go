@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// bytesPerToken approximates the size of a model token in prompt text.
+const bytesPerToken = 4
+
+type BudgetConfig struct {
+	// MaxBytes limits the size of a single review prompt. Zero disables the limit.
+	MaxBytes int `yaml:"max_bytes"`
+	// MaxTokens limits the approximate number of tokens of a single review
+	// prompt. Zero disables the limit.
+	MaxTokens int `yaml:"max_tokens"`
+}
+
+// Limit returns the prompt budget in bytes, the stricter of both limits, or 0
+// if prompts are not limited.
+func (b BudgetConfig) Limit() int {
+	limit := b.MaxBytes
+	if t := b.MaxTokens * bytesPerToken; t > 0 && (limit == 0 || t < limit) {
+		limit = t
+	}
+	return limit
+}
+
+// chunkReview splits review into consecutive parts, in comment order, whose
+// prompts rendered by f fit into limit bytes. A comment too large for the
+// budget on its own becomes a part of its own.
+func chunkReview(f ReviewFormatter, review *FormattedReview, limit int) ([]*FormattedReview, error) {
+	comments := slices.Clone(review.Comments)
+	slices.SortFunc(comments, compareFormattedComment)
+	newChunk := func() *FormattedReview {
+		chunk := *review
+		chunk.Comments = nil
+		// Measure with the widest part header so numbering never overflows the budget.
+		chunk.Part, chunk.Parts = len(comments), len(comments)
+		return &chunk
+	}
+	var chunks []*FormattedReview
+	cur := newChunk()
+	for _, c := range comments {
+		cur.Comments = append(cur.Comments, c)
+		if len(cur.Comments) == 1 {
+			continue
+		}
+		prompt, err := formatReview(f, cur)
+		if err != nil {
+			return nil, err
+		}
+		if len(prompt) > limit {
+			cur.Comments = cur.Comments[:len(cur.Comments)-1]
+			chunks = append(chunks, cur)
+			cur = newChunk()
+			cur.Comments = append(cur.Comments, c)
+		}
+	}
+	chunks = append(chunks, cur)
+	for i, chunk := range chunks {
+		chunk.Part, chunk.Parts = i+1, len(chunks)
+	}
+	return chunks, nil
+}
+
+// budgetParts returns the parts of review whose prompts rendered by f fit
+// into limit bytes, or review alone if it fits or limit is 0.
+func budgetParts(f ReviewFormatter, review *FormattedReview, limit int) ([]*FormattedReview, error) {
+	if limit <= 0 || len(review.Comments) < 2 {
+		return []*FormattedReview{review}, nil
+	}
+	prompt, err := formatReview(f, review)
+	if err != nil || len(prompt) <= limit {
+		return []*FormattedReview{review}, err
+	}
+	return chunkReview(f, review, limit)
+}
+
+// followChanges moves the line comments of part, located in the working tree
+// at dir when snapshot was taken, along with the changes made to it since and
+// re-reads their source context.
+func followChanges(ctx context.Context, p *Project, dir string, part *FormattedReview, snapshot string) {
+	for i, c := range part.Comments {
+		if c.Line > 0 && !c.IsOldSide {
+			part.Comments[i].IsOldSide, part.Comments[i].OldCommit = true, snapshot
+		}
+		part.Comments[i].Context = ""
+	}
+	mapOldSide(ctx, dir, part)
+	if n := p.Config().Review.ContextLines; n > 0 {
+		embedContext(ctx, dir, part, n)
+	}
+}
+
+func writePart(s *strings.Builder, i, n int, output []byte) {
+	fmt.Fprintf(s, "== part %d/%d\n%s\n", i+1, n, strings.TrimRight(string(output), "\n"))
+}
+
+// partProgress is called before a part of a review is sent, with a warning
+// if the part exceeds the prompt budget.
+type partProgress func(part, parts int, warning string)
+
+// runReview runs the agent in dir, the directory of p or a worktree of it, on
+// review. A review exceeding the prompt budget is sent in parts, one after
+// the other as later parts may depend on the changes made for earlier ones.
+// Each part is rendered only once the previous one is done, following the
+// lines it comments on. It stops at the first failure. progress, if set, is
+// called before each part of several and for a review too large for the
+// budget, which happens when a single comment exceeds it.
+func runReview(ctx context.Context, p *Project, dir string, agent AgentSection, review *FormattedReview, progress partProgress) ([]byte, error) {
+	review, err := prepareReview(ctx, p, dir, review)
+	if err != nil {
+		return nil, err
+	}
+	f, err := projectFormatter(p)
+	if err != nil {
+		return nil, err
+	}
+	limit := p.Config().Review.Budget.Limit()
+	parts, err := budgetParts(f, review, limit)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot string
+	if len(parts) > 1 {
+		if snapshot, _, err = snapshotCommit(ctx, dir, "tcr review parts"); err != nil {
+			return nil, fmt.Errorf("snapshot %s: %w", dir, err)
+		}
+	}
+	var s strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			followChanges(ctx, p, dir, part, snapshot)
+		}
+		prompt, err := formatReview(f, part)
+		if err != nil {
+			return []byte(s.String()), err
+		}
+		var warning string
+		if limit > 0 && len(prompt) > limit {
+			warning = fmt.Sprintf("part %d of %d exceeds the prompt budget (%d > %d bytes)", i+1, len(parts), len(prompt), limit)
+		}
+		if progress != nil && (len(parts) > 1 || warning != "") {
+			progress(i+1, len(parts), warning)
+		}
+		output, err := runAgent(ctx, p, dir, agent, prompt)
+		if len(parts) == 1 {
+			return output, err
+		}
+		writePart(&s, i, len(parts), output)
+		if err != nil {
+			return []byte(s.String()), fmt.Errorf("part %d of %d: %w", i+1, len(parts), err)
+		}
+	}
+	return []byte(s.String()), nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudgetConfig_Limit(t *testing.T) {
+	require.Equal(t, 0, BudgetConfig{}.Limit())
+	require.Equal(t, 1000, BudgetConfig{MaxBytes: 1000}.Limit())
+	require.Equal(t, 400, BudgetConfig{MaxTokens: 100}.Limit())
+	require.Equal(t, 400, BudgetConfig{MaxBytes: 1000, MaxTokens: 100}.Limit())
+	require.Equal(t, 800, BudgetConfig{MaxBytes: 800, MaxTokens: 1000}.Limit())
+}
+
+// chunkPrompts renders the parts of review within limit.
+func chunkPrompts(t *testing.T, f ReviewFormatter, review *FormattedReview, limit int) []string {
+	t.Helper()
+	parts, err := budgetParts(f, review, limit)
+	require.NoError(t, err)
+	prompts := make([]string, len(parts))
+	for i, part := range parts {
+		prompts[i], err = formatReview(f, part)
+		require.NoError(t, err)
+	}
+	return prompts
+}
+
+func TestBudgetParts(t *testing.T) {
+	review := &FormattedReview{CommitSha: "abcdef0123", Notes: "Keep it simple."}
+	for i := range 10 {
+		review.Comments = append(review.Comments, FormattedComment{
+			File: "main.go", Line: i + 1, Type: "issue", Content: fmt.Sprintf("comment %d %s", i, strings.Repeat("x", 40)), Index: i,
+		})
+	}
+	f := promptFormatter{defaultPrompt}
+
+	prompts := chunkPrompts(t, f, review, 0)
+	require.Len(t, prompts, 1)
+	require.NotContains(t, prompts[0], "This is part")
+
+	limit := 700
+	prompts = chunkPrompts(t, f, review, limit)
+	require.Greater(t, len(prompts), 1)
+	for i, prompt := range prompts {
+		require.LessOrEqual(t, len(prompt), limit)
+		require.Contains(t, prompt, fmt.Sprintf("This is part %d of %d of the review.", i+1, len(prompts)))
+		require.Contains(t, prompt, "Keep it simple.")
+	}
+	// Comments keep their prompt order (later lines first) across the parts.
+	all := strings.Join(prompts, "")
+	last := -1
+	for i := 9; i >= 0; i-- {
+		pos := strings.Index(all, fmt.Sprintf("comment %d ", i))
+		require.Greater(t, pos, last)
+		require.Equal(t, 1, strings.Count(all, fmt.Sprintf("comment %d ", i)))
+		last = pos
+	}
+
+	// A comment larger than the budget is sent on its own.
+	require.Len(t, chunkPrompts(t, f, review, 10), 10)
+}
+
+func TestRunReview(t *testing.T) {
+	_, local := setupBareRepo(t)
+	file := filepath.Join(local, "code.txt")
+	require.NoError(t, os.WriteFile(file, []byte("1\n2\n3\n4\n5\n6\n"), 0644))
+	gitOutput(t, local, "add", "code.txt")
+	gitOutput(t, local, "commit", "-qm", "code")
+	c := cfg.clone()
+	c.Review.ContextLines = 0
+	c.Review.Budget = BudgetConfig{MaxBytes: 350}
+	p := &Project{owner: "o", repo: "r", path: local, config: &c}
+	// The agent records its prompts and inserts a line at the top of the file.
+	prompts := filepath.Join(t.TempDir(), "prompts")
+	agent := AgentSection{Agent: "sh", Args: []string{"-c", `printf '%s\n---\n' "$0" >> ` + prompts + `; sed -i 1i0 code.txt; case "$0" in *"part 3"*) echo broken; exit 1;; esac; echo fixed`}}
+	review := &FormattedReview{Comments: []FormattedComment{
+		{File: "code.txt", Line: 5, Type: "issue", Content: "five"},
+		{File: "code.txt", Line: 2, Type: "issue", Content: "two"},
+		{File: "code.txt", Line: 1, Type: "issue", Content: strings.Repeat("long ", 80)},
+	}}
+
+	var progress []string
+	output, err := runReview(context.Background(), p, local, agent, review, func(part, parts int, warning string) {
+		progress = append(progress, fmt.Sprintf("%d/%d %s", part, parts, warning))
+	})
+	require.ErrorContains(t, err, "part 3 of 3")
+	require.Equal(t, "== part 1/3\nfixed\n== part 2/3\nfixed\n== part 3/3\nbroken\n", string(output))
+	require.Len(t, progress, 3)
+	require.Equal(t, "1/3 ", progress[0])
+	require.Regexp(t, `^3/3 part 3 of 3 exceeds the prompt budget \(\d+ > 350 bytes\)$`, progress[2])
+
+	// Each part follows the lines inserted for the parts before it.
+	data, err := os.ReadFile(prompts)
+	require.NoError(t, err)
+	sent := strings.Split(string(data), "\n---\n")
+	require.Contains(t, sent[0], "`code.txt:5`:\nfive")
+	require.Contains(t, sent[1], "`code.txt:3`:\ntwo")
+	require.Contains(t, sent[2], "`code.txt:3`:\nlong")
+}
+
+func TestRunReview_single(t *testing.T) {
+	_, local := setupBareRepo(t)
+	p := &Project{owner: "o", repo: "r", path: local}
+	agent := AgentSection{Agent: "sh", Args: []string{"-c", `echo fixed`}}
+	review := &FormattedReview{Comments: []FormattedComment{{File: "README.md", Type: "issue", Content: "x"}}}
+
+	output, err := runReview(context.Background(), p, local, agent, review, func(int, int, string) { t.Fatal("no progress expected") })
+	require.NoError(t, err)
+	require.Equal(t, "fixed\n", string(output))
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CheckpointsConfig controls the snapshots of the working tree taken before
+// every agent run.
+type CheckpointsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Keep is the number of checkpoints kept per project. Zero keeps all.
+	Keep int `yaml:"keep"`
+}
+
+var defaultCheckpointsConfig = CheckpointsConfig{Enabled: true, Keep: 50}
+
+// checkpointRefPrefix holds the checkpoints. Refs under refs/worktree are
+// kept per worktree and, like all refs outside refs/heads and refs/tags, are
+// not shown by git branch or pushed by default.
+const checkpointRefPrefix = "refs/worktree/tcr/checkpoints/"
+
+// checkpointBranchTrailer is the trailer of checkpoint commits naming the
+// branch they were taken on.
+const checkpointBranchTrailer = "Branch"
+
+// checkpointIdent is the author of checkpoint commits, so they can be
+// created without a configured git identity.
+var checkpointIdent = []string{
+	"GIT_AUTHOR_NAME=tcr", "GIT_AUTHOR_EMAIL=tcr@localhost",
+	"GIT_COMMITTER_NAME=tcr", "GIT_COMMITTER_EMAIL=tcr@localhost",
+}
+
+// Checkpoint is a snapshot of the working tree of a project, including
+// untracked files, stored as a commit whose parent is the HEAD at the time.
+type Checkpoint struct {
+	ID     string
+	Sha    string
+	Label  string
+	Time   time.Time
+	Parent string
+	// Branch is the branch checked out at the time, HEAD if detached.
+	Branch string
+	// Stat summarizes the changes made to the working tree since.
+	Stat string
+}
+
+func (c *Checkpoint) Ref() string { return checkpointRefPrefix + c.ID }
+
+func (c *Checkpoint) Title() string {
+	return fmt.Sprintf("%s · %s", c.Time.Local().Format("2006-01-02 15:04:05"), c.Label)
+}
+
+func (c *Checkpoint) Description() string {
+	if c.Stat == "" {
+		return "on " + c.Branch + " · no changes since"
+	}
+	return "on " + c.Branch + " · " + c.Stat + " since"
+}
+
+func (c *Checkpoint) FilterValue() string { return c.Label }
+
+// git runs git in dir with env added to its environment.
+func git(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	out, err := executeEnv(ctx, dir, env, "git", args...)
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// snapshotTree writes the working tree at dir, including untracked but not
+// ignored files, as a tree object without touching the index.
+func snapshotTree(ctx context.Context, dir string) (string, error) {
+	tmp, err := os.MkdirTemp("", "tcr-index-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(tmp, "index")}
+	if _, err := git(ctx, dir, nil, "rev-parse", "--verify", "-q", "HEAD"); err == nil {
+		if _, err := git(ctx, dir, env, "read-tree", "HEAD"); err != nil {
+			return "", err
+		}
+	}
+	if _, err := git(ctx, dir, env, "add", "-A"); err != nil {
+		return "", err
+	}
+	return git(ctx, dir, env, "write-tree")
+}
+
+// snapshotCommit stores the working tree at dir as a commit with message on
+// top of HEAD, if there is one, and returns it along with its parent.
+func snapshotCommit(ctx context.Context, dir, message string) (sha, parent string, err error) {
+	tree, err := snapshotTree(ctx, dir)
+	if err != nil {
+		return "", "", err
+	}
+	args := []string{"commit-tree", tree, "-m", message}
+	if head, err := git(ctx, dir, nil, "rev-parse", "--verify", "-q", "HEAD"); err == nil {
+		parent = head
+		args = append(args, "-p", head)
+	}
+	sha, err = git(ctx, dir, checkpointIdent, args...)
+	return sha, parent, err
+}
+
+// createCheckpoint snapshots the working tree of p before an agent run
+// described by label and removes checkpoints beyond the configured number.
+func createCheckpoint(ctx context.Context, p *Project, label string) (*Checkpoint, error) {
+	now := time.Now()
+	cp := &Checkpoint{ID: newRecordID(now), Label: label, Time: now}
+	var err error
+	if cp.Branch, err = headRef(ctx, p.path); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", p.Title(), err)
+	}
+	message := fmt.Sprintf("%s\n\n%s: %s", label, checkpointBranchTrailer, cp.Branch)
+	if cp.Sha, cp.Parent, err = snapshotCommit(ctx, p.path, message); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", p.Title(), err)
+	}
+	if _, err := git(ctx, p.path, nil, "update-ref", cp.Ref(), cp.Sha); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", p.Title(), err)
+	}
+	if keep := p.Config().Checkpoints.Keep; keep > 0 {
+		cps, err := listCheckpoints(ctx, p.path)
+		if err != nil {
+			return cp, err
+		}
+		for _, old := range cps[min(keep, len(cps)):] {
+			if _, err := git(ctx, p.path, nil, "update-ref", "-d", old.Ref()); err != nil {
+				return cp, err
+			}
+		}
+	}
+	return cp, nil
+}
+
+// checkpointBefore takes a checkpoint of p before an agent run described by
+// label if checkpoints are enabled.
+func checkpointBefore(ctx context.Context, p *Project, label string) error {
+	if !p.Config().Checkpoints.Enabled {
+		return nil
+	}
+	_, err := createCheckpoint(ctx, p, label)
+	return err
+}
+
+// listCheckpoints returns the checkpoints of the repository at dir, newest
+// first.
+func listCheckpoints(ctx context.Context, dir string) ([]*Checkpoint, error) {
+	out, err := git(ctx, dir, nil, "for-each-ref", "--sort=-refname",
+		"--format=%(refname)%00%(objectname)%00%(creatordate:unix)%00%(parent)%00%(trailers:key="+checkpointBranchTrailer+",valueonly,separator=%x2C)%00%(contents:subject)", checkpointRefPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var cps []*Checkpoint
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\x00", 6)
+		if len(fields) != 6 {
+			continue
+		}
+		unix, _ := strconv.ParseInt(fields[2], 10, 64)
+		cps = append(cps, &Checkpoint{
+			ID:     strings.TrimPrefix(fields[0], checkpointRefPrefix),
+			Sha:    fields[1],
+			Time:   time.Unix(unix, 0),
+			Parent: fields[3],
+			Branch: fields[4],
+			Label:  fields[5],
+		})
+	}
+	return cps, nil
+}
+
+// Checkpoints returns the checkpoints of p, newest first, each with the
+// changes made since.
+func (p *Project) Checkpoints(ctx context.Context) ([]*Checkpoint, error) {
+	cps, err := listCheckpoints(ctx, p.path)
+	if err != nil || len(cps) == 0 {
+		return cps, err
+	}
+	tree, err := snapshotTree(ctx, p.path)
+	if err != nil {
+		return nil, err
+	}
+	for _, cp := range cps {
+		if cp.Stat, err = git(ctx, p.path, nil, "diff", "--shortstat", cp.Sha, tree); err != nil {
+			return nil, err
+		}
+	}
+	return cps, nil
+}
+
+// rollbackLabelPrefix starts the label of the checkpoints taken before a
+// rollback.
+const rollbackLabelPrefix = "before rollback"
+
+// lastAgentCheckpoint returns the newest of cps taken on branch before an
+// agent run.
+func lastAgentCheckpoint(cps []*Checkpoint, branch string) *Checkpoint {
+	for _, cp := range cps {
+		if cp.Branch == branch && !strings.HasPrefix(cp.Label, rollbackLabelPrefix) {
+			return cp
+		}
+	}
+	return nil
+}
+
+// restoreCheckpoint resets p to the HEAD and working tree of cp. The state
+// replaced is checkpointed first so the rollback can be undone. Changes
+// staged at the time are restored as unstaged changes. It refuses to reset
+// a branch other than the one cp was taken on.
+func restoreCheckpoint(ctx context.Context, p *Project, cp *Checkpoint) error {
+	branch, err := headRef(ctx, p.path)
+	if err != nil {
+		return err
+	}
+	if cp.Branch != branch {
+		return fmt.Errorf("checkpoint %s was taken on %s but %s is checked out", cp.Title(), cmp.Or(cp.Branch, "an unknown branch"), branch)
+	}
+	if _, err := createCheckpoint(ctx, p, rollbackLabelPrefix+" to "+cp.Time.Local().Format(time.DateTime)); err != nil {
+		return err
+	}
+	if cp.Parent != "" {
+		if _, err := git(ctx, p.path, nil, "reset", "-q", "--hard", cp.Parent); err != nil {
+			return err
+		}
+	}
+	if _, err := git(ctx, p.path, nil, "clean", "-q", "-fd"); err != nil {
+		return err
+	}
+	// read-tree also removes the files missing from the checkpoint; the
+	// final reset leaves its contents in the working tree only.
+	if _, err := git(ctx, p.path, nil, "read-tree", "-u", "--reset", cp.Sha); err != nil {
+		return err
+	}
+	if cp.Parent == "" {
+		_, err := git(ctx, p.path, nil, "rm", "-q", "-r", "--cached", ".")
+		return err
+	}
+	_, err = git(ctx, p.path, nil, "reset", "-q")
+	return err
+}
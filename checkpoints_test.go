@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpoints(t *testing.T) {
+	ctx := context.Background()
+	p := tcrTestProject(t)
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(p.path, name), []byte(content), 0644))
+	}
+	write("README.md", "work in progress")
+	write("notes.txt", "untracked")
+	head := gitOutput(t, p.path, "rev-parse", "HEAD")
+	branch := gitOutput(t, p.path, "branch", "--show-current")
+	status := gitOutput(t, p.path, "status", "--porcelain")
+
+	cp, err := createCheckpoint(ctx, p, "crush on review r1")
+	require.NoError(t, err)
+	require.Equal(t, head, cp.Parent)
+	require.Equal(t, branch, cp.Branch)
+	require.Equal(t, status, gitOutput(t, p.path, "status", "--porcelain"), "the index is left alone")
+
+	// The agent commits, changes and deletes files.
+	write("main.go", "package main")
+	gitOutput(t, p.path, "add", "main.go")
+	gitOutput(t, p.path, "commit", "-qm", "agent")
+	write("README.md", "trashed")
+	require.NoError(t, os.Remove(filepath.Join(p.path, "notes.txt")))
+	write("junk.txt", "junk")
+
+	cps, err := p.Checkpoints(ctx)
+	require.NoError(t, err)
+	require.Len(t, cps, 1)
+	require.Equal(t, "crush on review r1", cps[0].Label)
+	require.Equal(t, cp.Sha, cps[0].Sha)
+	require.Equal(t, branch, cps[0].Branch)
+	require.Contains(t, cps[0].Description(), "4 files changed")
+
+	require.NoError(t, restoreCheckpoint(ctx, p, lastAgentCheckpoint(cps, branch)))
+	require.Equal(t, head, gitOutput(t, p.path, "rev-parse", "HEAD"))
+	require.Equal(t, status, gitOutput(t, p.path, "status", "--porcelain"))
+	data, err := os.ReadFile(filepath.Join(p.path, "README.md"))
+	require.NoError(t, err)
+	require.Equal(t, "work in progress", string(data))
+	require.NoFileExists(t, filepath.Join(p.path, "junk.txt"))
+	require.NoFileExists(t, filepath.Join(p.path, "main.go"))
+
+	cps, err = listCheckpoints(ctx, p.path)
+	require.NoError(t, err)
+	require.Len(t, cps, 2)
+	require.Contains(t, cps[0].Label, rollbackLabelPrefix)
+	require.Equal(t, cp.Sha, lastAgentCheckpoint(cps, branch).Sha)
+
+	// Rolling back the rollback brings the agent's changes back.
+	require.NoError(t, restoreCheckpoint(ctx, p, cps[0]))
+	require.FileExists(t, filepath.Join(p.path, "junk.txt"))
+	require.NotEqual(t, head, gitOutput(t, p.path, "rev-parse", "HEAD"))
+
+	// Checkpoints of another branch are not rolled back to.
+	gitOutput(t, p.path, "stash", "-q", "-u")
+	gitOutput(t, p.path, "checkout", "-q", "-b", "other")
+	require.Nil(t, lastAgentCheckpoint(cps, "other"))
+	err = restoreCheckpoint(ctx, p, cp)
+	require.ErrorContains(t, err, "was taken on "+branch+" but other is checked out")
+	require.Equal(t, gitOutput(t, p.path, "rev-parse", branch), gitOutput(t, p.path, "rev-parse", "HEAD"))
+	gitOutput(t, p.path, "checkout", "-q", "--detach")
+	cp, err = createCheckpoint(ctx, p, "detached")
+	require.NoError(t, err)
+	require.Equal(t, "HEAD", cp.Branch)
+
+	p.config.Checkpoints.Keep = 2
+	_, err = createCheckpoint(ctx, p, "interactive crush")
+	require.NoError(t, err)
+	cps, err = listCheckpoints(ctx, p.path)
+	require.NoError(t, err)
+	require.Len(t, cps, 2)
+	require.Equal(t, "interactive crush", cps[0].Label)
+	require.Equal(t, "detached", cps[1].Label)
+	require.Empty(t, gitOutput(t, p.path, "branch", "--list", "*tcr*"))
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type CheckpointsAction int
+
+const (
+	CheckpointsActionNone CheckpointsAction = iota
+	CheckpointsActionRollback
+	CheckpointsActionBack
+)
+
+type checkpointsSelectedMsg struct {
+	action     CheckpointsAction
+	checkpoint *Checkpoint
+}
+
+type checkpointsKeyMap struct {
+	Rollback key.Binding
+	Back     key.Binding
+}
+
+func (k checkpointsKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Rollback, k.Back}
+}
+
+func defaultCheckpointsKeyMap() checkpointsKeyMap {
+	return checkpointsKeyMap{
+		Rollback: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "roll back")),
+		Back:     key.NewBinding(key.WithKeys("esc", "q"), key.WithHelp("esc/q", "back")),
+	}
+}
+
+// CheckpointList lists the checkpoints of a project with the changes made
+// since each.
+type CheckpointList struct {
+	project *Project
+	list    list.Model
+	keyMap  checkpointsKeyMap
+}
+
+func NewCheckpointList(p *Project, cps []*Checkpoint, width, height int) *CheckpointList {
+	items := make([]list.Item, len(cps))
+	for i, cp := range cps {
+		items[i] = cp
+	}
+	keyMap := defaultCheckpointsKeyMap()
+	l := list.New(items, list.NewDefaultDelegate(), width, height)
+	l.Title = p.Title() + " – checkpoints"
+	l.SetStatusBarItemName("checkpoint", "checkpoints")
+	l.KeyMap.Quit.SetEnabled(false)
+	l.AdditionalFullHelpKeys = keyMap.ShortHelp
+	l.AdditionalShortHelpKeys = keyMap.ShortHelp
+	return &CheckpointList{project: p, list: l, keyMap: keyMap}
+}
+
+func (l *CheckpointList) Init() tea.Cmd { return nil }
+
+func (l *CheckpointList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if l.list.SettingFilter() {
+			break
+		}
+		switch {
+		case key.Matches(msg, l.keyMap.Rollback):
+			if cp, ok := l.list.SelectedItem().(*Checkpoint); ok {
+				return l, func() tea.Msg { return checkpointsSelectedMsg{action: CheckpointsActionRollback, checkpoint: cp} }
+			}
+		case key.Matches(msg, l.keyMap.Back) && l.list.FilterState() == list.Unfiltered:
+			return l, func() tea.Msg { return checkpointsSelectedMsg{action: CheckpointsActionBack} }
+		}
+	case tea.WindowSizeMsg:
+		l.list.SetSize(msg.Width, msg.Height)
+	}
+	var cmd tea.Cmd
+	l.list, cmd = l.list.Update(msg)
+	return l, cmd
+}
+
+func (l *CheckpointList) View() string { return l.list.View() }
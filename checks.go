@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// checkKinds are the kinds of project commands, in the order they are run.
+var checkKinds = []string{"test", "lint", "build"}
+
+// field returns the command of kind in c, nil for an unknown kind.
+func (c *CommandsConfig) field(kind string) *string {
+	switch kind {
+	case "test":
+		return &c.Test
+	case "lint":
+		return &c.Lint
+	case "build":
+		return &c.Build
+	}
+	return nil
+}
+
+// command returns the command of kind.
+func (c CommandsConfig) command(kind string) string {
+	if f := c.field(kind); f != nil {
+		return *f
+	}
+	return ""
+}
+
+// setDefault sets the command of kind unless c already has one.
+func (c *CommandsConfig) setDefault(kind, command string) {
+	if f := c.field(kind); f != nil && *f == "" {
+		*f = command
+	}
+}
+
+// withDefaults fills the commands missing from c with those of d.
+func (c CommandsConfig) withDefaults(d CommandsConfig) CommandsConfig {
+	for _, kind := range checkKinds {
+		c.setDefault(kind, d.command(kind))
+	}
+	return c
+}
+
+var makeTargetRE = regexp.MustCompile(`(?m)^(test|lint|build):`)
+
+// detectCommands guesses the commands of the project at dir from its build
+// files. Makefile targets win over the tools of the project's language.
+func detectCommands(dir string) CommandsConfig {
+	var c CommandsConfig
+	if data, err := os.ReadFile(filepath.Join(dir, "Makefile")); err == nil {
+		for _, m := range makeTargetRE.FindAllStringSubmatch(string(data), -1) {
+			c.setDefault(m[1], "make "+m[1])
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+		c = c.withDefaults(CommandsConfig{Test: "go test ./...", Lint: "go vet ./...", Build: "go build ./..."})
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Cargo.toml")); err == nil {
+		c = c.withDefaults(CommandsConfig{Test: "cargo test", Lint: "cargo clippy", Build: "cargo build"})
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		var pkg struct {
+			Scripts map[string]string `json:"scripts"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			for _, kind := range checkKinds {
+				if _, ok := pkg.Scripts[kind]; ok {
+					c.setDefault(kind, "npm run "+kind)
+				}
+			}
+		}
+	}
+	return c
+}
+
+// Commands returns the commands of p: the configured ones, completed by
+// those detected from the project's build files.
+func (p *Project) Commands() CommandsConfig {
+	return p.Config().Commands.withDefaults(detectCommands(p.path))
+}
+
+// CheckResult is the outcome of running a project command.
+type CheckResult struct {
+	Kind      string    `json:"kind"`
+	Command   string    `json:"command"`
+	Passed    bool      `json:"passed"`
+	ExitCode  int       `json:"exit_code"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Output    string    `json:"output,omitempty"`
+}
+
+// Badge is the short form of the result shown next to the project.
+func (r *CheckResult) Badge() string {
+	mark := "✓"
+	if !r.Passed {
+		mark = "✗"
+	}
+	return fmt.Sprintf("%s %s %s", r.Kind, mark, r.EndedAt.Local().Format("Jan 2 15:04"))
+}
+
+func (r *CheckResult) Title() string {
+	status := "passed"
+	if !r.Passed {
+		status = fmt.Sprintf("failed (exit code %d)", r.ExitCode)
+	}
+	return fmt.Sprintf("%s · %s", r.Kind, status)
+}
+
+func (r *CheckResult) Description() string {
+	return fmt.Sprintf("%s · %s · %s", r.Command, r.EndedAt.Local().Format(time.DateTime), r.EndedAt.Sub(r.StartedAt).Round(time.Millisecond))
+}
+
+func (r *CheckResult) FilterValue() string { return r.Kind }
+
+// Details renders the result and the command output for display.
+func (r *CheckResult) Details() string {
+	return fmt.Sprintf("$ %s\n%s\n\n%s", r.Command, r.Description(), r.Output)
+}
+
+// checkStore holds the results of p. They describe the files of one tree, so
+// each worktree keeps its own below those of the main clone.
+func (p *Project) checkStore() recordStore[CheckResult] {
+	dir := p.stateDir("checks")
+	if p.root != "" {
+		dir = filepath.Join(dir, "worktrees", url.PathEscape(p.branch))
+	}
+	return recordStore[CheckResult]{dir: dir}
+}
+
+// Checks returns the last result of each kind of command run in p.
+func (p *Project) Checks() ([]*CheckResult, error) {
+	results, err := p.checkStore().List()
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(results, func(a, b *CheckResult) int {
+		return slices.Index(checkKinds, a.Kind) - slices.Index(checkKinds, b.Kind)
+	})
+	return results, nil
+}
+
+// runCheck runs the command of kind in p and stores its result.
+func runCheck(ctx context.Context, p *Project, kind string) (*CheckResult, error) {
+	command := p.Commands().command(kind)
+	if command == "" {
+		return nil, fmt.Errorf("no %s command configured or detected for %s", kind, p.Title())
+	}
+	r := &CheckResult{Kind: kind, Command: command, StartedAt: time.Now()}
+	output, err := executeEnv(ctx, p.path, p.Config().environ(), "sh", "-c", command)
+	r.EndedAt = time.Now()
+	r.Output = string(output)
+	r.Passed = err == nil
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("run %s: %w", command, err)
+		}
+		r.ExitCode = exitErr.ExitCode()
+	}
+	return r, p.checkStore().Save(kind, r)
+}
+
+// runChecks runs every command of p, stopping early only if ctx is done.
+func runChecks(ctx context.Context, p *Project) ([]*CheckResult, error) {
+	commands := p.Commands()
+	var results []*CheckResult
+	for _, kind := range checkKinds {
+		if commands.command(kind) == "" {
+			continue
+		}
+		r, err := runCheck(ctx, p, kind)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, r)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no commands configured or detected for %s", p.Title())
+	}
+	return results, nil
+}
+
+// checksSummary reports the outcome of results in one line.
+func checksSummary(results []*CheckResult) string {
+	parts := make([]string, len(results))
+	for i, r := range results {
+		parts[i] = r.Title()
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectCommands(t *testing.T) {
+	write := func(dir, name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	require.Equal(t, CommandsConfig{}, detectCommands(t.TempDir()))
+
+	dir := t.TempDir()
+	write(dir, "go.mod", "module x\n")
+	write(dir, "Makefile", "lint:\n\tgolangci-lint run\n")
+	require.Equal(t, CommandsConfig{Test: "go test ./...", Lint: "make lint", Build: "go build ./..."}, detectCommands(dir))
+
+	dir = t.TempDir()
+	write(dir, "package.json", `{"scripts": {"test": "vitest", "build": "tsc"}}`)
+	require.Equal(t, CommandsConfig{Test: "npm run test", Build: "npm run build"}, detectCommands(dir))
+
+	dir = t.TempDir()
+	write(dir, "Cargo.toml", "[package]\n")
+	require.Equal(t, CommandsConfig{Test: "cargo test", Lint: "cargo clippy", Build: "cargo build"}, detectCommands(dir))
+}
+
+func TestRunChecks(t *testing.T) {
+	p := testProject(t)
+	require.NoError(t, os.WriteFile(filepath.Join(p.path, "Makefile"), []byte("test:\nbuild:\n"), 0644))
+	p.config.Commands = CommandsConfig{Test: "echo ok", Lint: "echo bad; exit 4"}
+	require.Equal(t, CommandsConfig{Test: "echo ok", Lint: "echo bad; exit 4", Build: "make build"}, p.Commands())
+
+	p.config.Commands.Build = "true"
+	results, err := runChecks(context.Background(), p)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Equal(t, "test · passed, lint · failed (exit code 4), build · passed", checksSummary(results))
+	require.Equal(t, "ok\n", results[0].Output)
+
+	checks, err := p.Checks()
+	require.NoError(t, err)
+	require.Equal(t, checksSummary(results), checksSummary(checks))
+
+	p.checks = checks
+	ts := func(r *CheckResult) string { return r.EndedAt.Local().Format("Jan 2 15:04") }
+	require.Equal(t, "test ✓ "+ts(results[0])+" · lint ✗ "+ts(results[1])+" · build ✓ "+ts(results[2]), p.Description())
+
+	_, err = runCheck(context.Background(), &Project{path: t.TempDir(), owner: "o", repo: "r"}, "lint")
+	require.ErrorContains(t, err, "no lint command configured or detected for o/r")
+}
+
+func TestCheckResult_Badge(t *testing.T) {
+	ended := time.Date(2026, 5, 4, 13, 7, 0, 0, time.Local)
+	require.Equal(t, "test ✓ May 4 13:07", (&CheckResult{Kind: "test", Passed: true, EndedAt: ended}).Badge())
+	require.Equal(t, "lint ✗ May 4 13:07", (&CheckResult{Kind: "lint", EndedAt: ended}).Badge())
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type checksFinishedMsg struct {
+	project *Project
+	results []*CheckResult
+	err     error
+}
+
+// runChecksCmd runs the commands of the given kinds in p, all of them if
+// none are given.
+func runChecksCmd(p *Project, kinds ...string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		if len(kinds) == 0 {
+			results, err := runChecks(ctx, p)
+			return checksFinishedMsg{project: p, results: results, err: err}
+		}
+		var results []*CheckResult
+		for _, kind := range kinds {
+			r, err := runCheck(ctx, p, kind)
+			if err != nil {
+				return checksFinishedMsg{project: p, results: results, err: err}
+			}
+			results = append(results, r)
+		}
+		return checksFinishedMsg{project: p, results: results}
+	}
+}
+
+type ChecksAction int
+
+const (
+	ChecksActionNone ChecksAction = iota
+	ChecksActionView
+	ChecksActionRerun
+	ChecksActionSendFailures
+	ChecksActionBack
+)
+
+type checksSelectedMsg struct {
+	action ChecksAction
+	result *CheckResult
+}
+
+type checksKeyMap struct {
+	View         key.Binding
+	Rerun        key.Binding
+	SendFailures key.Binding
+	Back         key.Binding
+}
+
+func (k checksKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.View, k.Rerun, k.SendFailures, k.Back}
+}
+
+func defaultChecksKeyMap() checksKeyMap {
+	return checksKeyMap{
+		View:         key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "output")),
+		Rerun:        key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "rerun")),
+		SendFailures: key.NewBinding(key.WithKeys("f"), key.WithHelp("f", "send failures to agent")),
+		Back:         key.NewBinding(key.WithKeys("esc", "q"), key.WithHelp("esc/q", "back")),
+	}
+}
+
+// ChecksView lists the last result of each command run in a project.
+type ChecksView struct {
+	project *Project
+	list    list.Model
+	keyMap  checksKeyMap
+}
+
+func NewChecksView(p *Project, results []*CheckResult, width, height int) *ChecksView {
+	keyMap := defaultChecksKeyMap()
+	l := list.New(nil, list.NewDefaultDelegate(), width, height)
+	l.Title = p.Title() + " – checks"
+	l.SetStatusBarItemName("check", "checks")
+	l.KeyMap.Quit.SetEnabled(false)
+	l.AdditionalFullHelpKeys = keyMap.ShortHelp
+	l.AdditionalShortHelpKeys = keyMap.ShortHelp
+	v := &ChecksView{project: p, list: l, keyMap: keyMap}
+	v.SetResults(results)
+	return v
+}
+
+func (v *ChecksView) SetResults(results []*CheckResult) tea.Cmd {
+	items := make([]list.Item, len(results))
+	for i, r := range results {
+		items[i] = r
+	}
+	return v.list.SetItems(items)
+}
+
+// Results returns the results listed.
+func (v *ChecksView) Results() []*CheckResult {
+	var results []*CheckResult
+	for _, item := range v.list.Items() {
+		if r, ok := item.(*CheckResult); ok {
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+func (v *ChecksView) Init() tea.Cmd { return nil }
+
+func (v *ChecksView) selected(action ChecksAction) tea.Cmd {
+	if r, ok := v.list.SelectedItem().(*CheckResult); ok {
+		return func() tea.Msg { return checksSelectedMsg{action: action, result: r} }
+	}
+	return nil
+}
+
+func (v *ChecksView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if v.list.SettingFilter() {
+			break
+		}
+		switch {
+		case key.Matches(msg, v.keyMap.View):
+			return v, v.selected(ChecksActionView)
+		case key.Matches(msg, v.keyMap.Rerun):
+			return v, v.selected(ChecksActionRerun)
+		case key.Matches(msg, v.keyMap.SendFailures):
+			return v, func() tea.Msg { return checksSelectedMsg{action: ChecksActionSendFailures} }
+		case key.Matches(msg, v.keyMap.Back) && v.list.FilterState() == list.Unfiltered:
+			return v, func() tea.Msg { return checksSelectedMsg{action: ChecksActionBack} }
+		}
+	case tea.WindowSizeMsg:
+		v.list.SetSize(msg.Width, msg.Height)
+	}
+	var cmd tea.Cmd
+	v.list, cmd = v.list.Update(msg)
+	return v, cmd
+}
+
+func (v *ChecksView) View() string { return v.list.View() }
@@ -2,8 +2,11 @@ package main
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,9 +16,80 @@ type AgentSection struct {
 	Args  []string `yaml:"args,omitempty"`
 }
 
+type ReviewConfig struct {
+	// Command and Args start the interactive review tool in the project directory.
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args,omitempty"`
+	// RangeArgs are appended to Args to review only the changes since the
+	// previous round; {base} is replaced with the previously reviewed commit.
+	// The default is tuicr's revision range flag; set it to [] for tools
+	// without one, so every round shows the tool's default view.
+	RangeArgs []string `yaml:"range_args,omitempty"`
+	// Capture selects how the review is collected from the tool: "stdout"
+	// (tuicr Markdown export), "session" (newest tuicr session file matching
+	// the Path glob) or "json" (a JSON review written to Path). With "stdout"
+	// the session notes and unreviewed files come from the session saved for
+	// the same commit, found via the Path glob or in tuicr's data directory.
+	Capture string `yaml:"capture"`
+	Path    string `yaml:"path,omitempty"`
+	// ContextLines is the number of source lines embedded above and below
+	// each commented line in review prompts. Zero disables embedding.
+	ContextLines int `yaml:"context_lines"`
+	// Format selects how reviews are rendered for the agent: markdown,
+	// tuicr, json or sarif.
+	Format string `yaml:"format"`
+	// Template is a text/template file used for Markdown prompts instead of the
+	// built-in layout. Relative paths are resolved against the config directory.
+	Template string `yaml:"template,omitempty"`
+	// ProjectTemplates overrides Template per project, keyed by "owner/repo".
+	ProjectTemplates map[string]string `yaml:"project_templates,omitempty"`
+	// Policy filters and orders the comments sent to the agent.
+	Policy ReviewPolicy `yaml:"policy"`
+	// Parallel runs one agent per file or directory of the review.
+	Parallel ParallelConfig `yaml:"parallel"`
+	// Budget limits the size of review prompts. Larger reviews are split into
+	// parts sent to the agent one after the other.
+	Budget BudgetConfig `yaml:"budget"`
+}
+
+// ProfileDefaults names the agent profiles used by default in each mode.
+// Empty names keep the interactive and non_interactive sections.
+type ProfileDefaults struct {
+	Interactive    string `yaml:"interactive,omitempty"`
+	NonInteractive string `yaml:"non_interactive,omitempty"`
+}
+
+// CommandsConfig holds the shell commands tcr runs in a project with sh -c.
+// Commands left empty are detected from the project's Makefile, go.mod,
+// Cargo.toml or package.json.
+type CommandsConfig struct {
+	Test  string `yaml:"test,omitempty"`
+	Lint  string `yaml:"lint,omitempty"`
+	Build string `yaml:"build,omitempty"`
+}
+
+// AgentConfig is the configuration of tcr. Settings apply in this order, later
+// ones winning: built-in defaults, config.yaml, the .tcr.yaml of the project
+// acted on and command line flags.
 type AgentConfig struct {
 	Interactive    AgentSection `yaml:"interactive"`
 	NonInteractive AgentSection `yaml:"non_interactive"`
+	// Profiles are named agents that can be picked when starting an
+	// interactive session or set as the default of a mode.
+	Profiles map[string]AgentSection `yaml:"profiles,omitempty"`
+	Defaults ProfileDefaults         `yaml:"defaults,omitempty"`
+	Review   ReviewConfig            `yaml:"review"`
+	GitHub   GitHubConfig            `yaml:"github"`
+	Commands CommandsConfig          `yaml:"commands,omitempty"`
+	Logs     LogsConfig              `yaml:"logs"`
+	TCR      TCRConfig               `yaml:"tcr"`
+	Feedback FeedbackConfig          `yaml:"feedback"`
+	// Checkpoints snapshot the working tree before every agent run.
+	Checkpoints CheckpointsConfig `yaml:"checkpoints"`
+	Worktrees   WorktreesConfig   `yaml:"worktrees"`
+	// Env is added to the environment of the agents, review tool and
+	// commands run in a project.
+	Env map[string]string `yaml:"env,omitempty"`
 }
 
 var defaultConfig = AgentConfig{
@@ -27,10 +101,118 @@ var defaultConfig = AgentConfig{
 		Agent: "pi",
 		Args:  []string{"--model", "claude-sonnet", "--print"},
 	},
+	Review: ReviewConfig{
+		Command:   "tuicr",
+		Args:      []string{"--stdout"},
+		RangeArgs: []string{"-r", "{base}..HEAD"},
+		Capture:   captureStdout,
+		Format:    defaultReviewFormat,
+		Policy:    defaultReviewPolicy,
+		Parallel:  defaultParallelConfig,
+	},
+	GitHub:      defaultGitHubConfig,
+	Logs:        defaultLogsConfig,
+	TCR:         defaultTCRConfig,
+	Feedback:    defaultFeedbackConfig,
+	Checkpoints: defaultCheckpointsConfig,
 }
 
 var cfg = defaultConfig
 
+// configDir is the directory holding config.yaml and files referenced from it.
+var configDir string
+
+// clone returns a copy of c whose maps can be changed without affecting c.
+func (c AgentConfig) clone() AgentConfig {
+	c.Profiles = maps.Clone(c.Profiles)
+	c.Env = maps.Clone(c.Env)
+	c.Review.ProjectTemplates = maps.Clone(c.Review.ProjectTemplates)
+	c.Review.Policy.Priorities = maps.Clone(c.Review.Policy.Priorities)
+	c.Feedback.Checks = slices.Clone(c.Feedback.Checks)
+	return c
+}
+
+// environ returns Env as KEY=value pairs in a stable order.
+func (c AgentConfig) environ() []string {
+	env := make([]string, 0, len(c.Env))
+	for _, k := range slices.Sorted(maps.Keys(c.Env)) {
+		env = append(env, k+"="+c.Env[k])
+	}
+	return env
+}
+
+// profileNames returns the names of the configured agent profiles in order.
+func (c AgentConfig) profileNames() []string { return slices.Sorted(maps.Keys(c.Profiles)) }
+
+func (c AgentConfig) profile(name string) (AgentSection, error) {
+	a, ok := c.Profiles[name]
+	if !ok {
+		return AgentSection{}, fmt.Errorf("unknown agent profile %q (available: %s)", name, strings.Join(c.profileNames(), ", "))
+	}
+	return a, nil
+}
+
+// resolveProfiles replaces the interactive and non-interactive sections with
+// the default profiles of their mode.
+func (c *AgentConfig) resolveProfiles() error {
+	for name, a := range c.Profiles {
+		if a.Agent == "" {
+			return fmt.Errorf("profiles.%s.agent must not be empty", name)
+		}
+	}
+	if name := c.Defaults.Interactive; name != "" {
+		a, err := c.profile(name)
+		if err != nil {
+			return fmt.Errorf("defaults.interactive: %w", err)
+		}
+		c.Interactive = a
+	}
+	if name := c.Defaults.NonInteractive; name != "" {
+		a, err := c.profile(name)
+		if err != nil {
+			return fmt.Errorf("defaults.non_interactive: %w", err)
+		}
+		c.NonInteractive = a
+	}
+	return nil
+}
+
+func (c AgentConfig) validate() error {
+	if c.Review.Command == "" {
+		return fmt.Errorf("review.command must not be empty")
+	}
+	if _, err := reviewAdapterFor(c.Review); err != nil {
+		return err
+	}
+	if _, err := formatterFor(c.Review.Format); err != nil {
+		return err
+	}
+	if b := c.Review.Budget; b.MaxBytes < 0 || b.MaxTokens < 0 {
+		return fmt.Errorf("review.budget limits must not be negative")
+	}
+	if l := c.Logs; l.MaxAgeDays < 0 || l.MaxFiles < 0 {
+		return fmt.Errorf("logs retention limits must not be negative")
+	}
+	if c.TCR.OutputLines < 0 {
+		return fmt.Errorf("tcr.output_lines must not be negative")
+	}
+	for _, kind := range c.Feedback.Checks {
+		if !slices.Contains(checkKinds, kind) {
+			return fmt.Errorf("feedback.checks: unknown command %q (available: %s)", kind, strings.Join(checkKinds, ", "))
+		}
+	}
+	if c.Checkpoints.Keep < 0 {
+		return fmt.Errorf("checkpoints.keep must not be negative")
+	}
+	if c.Feedback.MaxIterations < 0 {
+		return fmt.Errorf("feedback.max_iterations must not be negative")
+	}
+	if by := c.Review.Parallel.GroupBy; by != "file" && by != "dir" {
+		return fmt.Errorf("review.parallel.group_by must be file or dir, got %q", by)
+	}
+	return nil
+}
+
 func loadConfig() error {
 	configHome := os.Getenv("XDG_CONFIG_HOME")
 	if configHome == "" {
@@ -40,7 +222,8 @@ func loadConfig() error {
 		}
 		configHome = filepath.Join(home, ".config")
 	}
-	configPath := filepath.Join(configHome, "tcr", "config.yaml")
+	configDir = filepath.Join(configHome, "tcr")
+	configPath := filepath.Join(configDir, "config.yaml")
 
 	data, err := os.ReadFile(configPath)
 	if os.IsNotExist(err) {
@@ -61,10 +244,157 @@ func loadConfig() error {
 		return fmt.Errorf("could not read config file %s: %w", configPath, err)
 	}
 
-	var userConfig AgentConfig
+	// Start from the defaults so sections missing from the file keep their default values.
+	userConfig := defaultConfig.clone()
 	if err := yaml.Unmarshal(data, &userConfig); err != nil {
 		return fmt.Errorf("could not parse config file %s: %w", configPath, err)
 	}
+	if err := userConfig.resolveProfiles(); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", configPath, err)
+	}
+	if err := userConfig.validate(); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", configPath, err)
+	}
 	cfg = userConfig
 	return nil
 }
+
+// projectConfigName is the repository-local config file overlaying config.yaml.
+const projectConfigName = ".tcr.yaml"
+
+// overlayKeys are the settings a repository-local config may set, by section.
+// Sections with no keys listed may be set entirely. The GitHub settings, which
+// carry the token, and the files tcr reads and writes outside the project
+// stay with config.yaml.
+var overlayKeys = map[string][]string{
+	"interactive":     nil,
+	"non_interactive": nil,
+	"profiles":        nil,
+	"defaults":        nil,
+	"review":          {"command", "args", "range_args", "capture", "context_lines", "format", "template", "policy", "parallel", "budget"},
+	"commands":        nil,
+	"tcr":             nil,
+	"feedback":        nil,
+	"checkpoints":     nil,
+	"worktrees":       nil,
+	"env":             nil,
+}
+
+// checkOverlayKeys returns an error for the first setting in data not listed
+// in overlayKeys.
+func checkOverlayKeys(data []byte) error {
+	var doc map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	for _, key := range slices.Sorted(maps.Keys(doc)) {
+		keys, ok := overlayKeys[key]
+		if !ok {
+			return fmt.Errorf("%s cannot be set in %s", key, projectConfigName)
+		}
+		if keys == nil {
+			continue
+		}
+		var section map[string]yaml.Node
+		node := doc[key]
+		if err := node.Decode(&section); err != nil {
+			return err
+		}
+		for _, k := range slices.Sorted(maps.Keys(section)) {
+			if !slices.Contains(keys, k) {
+				return fmt.Errorf("%s.%s cannot be set in %s", key, k, projectConfigName)
+			}
+		}
+	}
+	return nil
+}
+
+// overlay returns a copy of c with the repository-local config data of the
+// project in dir applied on top. Only the settings in overlayKeys may be set.
+// Sections set in data replace those of c; defaults only pick profiles when
+// data sets them, so agents configured directly in data are kept. A review
+// template set in data must be a path within dir and takes precedence over
+// the project templates of c.
+func (c AgentConfig) overlay(dir string, data []byte) (AgentConfig, error) {
+	if err := checkOverlayKeys(data); err != nil {
+		return c, err
+	}
+	out := c.clone()
+	out.Defaults = ProfileDefaults{}
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return c, err
+	}
+	if t := out.Review.Template; t != c.Review.Template {
+		if t != "" {
+			if !filepath.IsLocal(t) {
+				return c, fmt.Errorf("review.template must be a relative path within the project, got %q", t)
+			}
+			out.Review.Template = filepath.Join(dir, t)
+		}
+		out.Review.ProjectTemplates = nil
+	}
+	if err := out.resolveProfiles(); err != nil {
+		return c, err
+	}
+	if reviewFormatFlag != "" {
+		out.Review.Format = reviewFormatFlag
+	}
+	if err := out.validate(); err != nil {
+		return c, err
+	}
+	return out, nil
+}
+
+// commandLines returns the programs c runs in a project and the environment
+// they get, one setting per line.
+func (c AgentConfig) commandLines() []string {
+	command := func(name string, args []string) string {
+		return strings.Join(append([]string{name}, args...), " ")
+	}
+	lines := []string{
+		"interactive: " + command(c.Interactive.Agent, c.Interactive.Args),
+		"non_interactive: " + command(c.NonInteractive.Agent, c.NonInteractive.Args),
+	}
+	for _, name := range c.profileNames() {
+		a := c.Profiles[name]
+		lines = append(lines, fmt.Sprintf("profiles.%s: %s", name, command(a.Agent, a.Args)))
+	}
+	lines = append(lines, "review.command: "+command(c.Review.Command, c.Review.Args))
+	if len(c.Review.RangeArgs) > 0 {
+		lines = append(lines, "review.range_args: "+strings.Join(c.Review.RangeArgs, " "))
+	}
+	for _, kind := range checkKinds {
+		if command := c.Commands.command(kind); command != "" {
+			lines = append(lines, fmt.Sprintf("commands.%s: %s", kind, command))
+		}
+	}
+	for _, kv := range c.environ() {
+		lines = append(lines, "env: "+kv)
+	}
+	return lines
+}
+
+// changedCommands returns the command lines of c that differ from those of
+// base, the configuration c overlays.
+func (c AgentConfig) changedCommands(base AgentConfig) []string {
+	old := base.commandLines()
+	return slices.DeleteFunc(c.commandLines(), func(l string) bool { return slices.Contains(old, l) })
+}
+
+// loadProjectConfig returns the configuration for the project in dir: the
+// global configuration overlaid with the project's .tcr.yaml, or nil if the
+// project has none.
+func loadProjectConfig(dir string) (*AgentConfig, error) {
+	data, err := os.ReadFile(filepath.Join(dir, projectConfigName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c, err := cfg.overlay(dir, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", projectConfigName, err)
+	}
+	return &c, nil
+}
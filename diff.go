@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// diffHunk is the header of a hunk in a unified diff.
+type diffHunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// parseHunkHeader parses a "@@ -a,b +c,d @@" line.
+func parseHunkHeader(line string) (diffHunk, bool) {
+	m := hunkHeaderRe.FindStringSubmatch(line)
+	if m == nil {
+		return diffHunk{}, false
+	}
+	return diffHunk{
+		OldStart: atoiDefault(m[1], 0),
+		OldLines: atoiDefault(m[2], 1),
+		NewStart: atoiDefault(m[3], 0),
+		NewLines: atoiDefault(m[4], 1),
+	}, true
+}
+
+// parseHunks returns the hunk headers of a unified diff in order.
+func parseHunks(diff []byte) []diffHunk {
+	var hunks []diffHunk
+	scanner := bufio.NewScanner(bytes.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if h, ok := parseHunkHeader(scanner.Text()); ok {
+			hunks = append(hunks, h)
+		}
+	}
+	return hunks
+}
+
+// mapOldLine translates a line of the old file to the new file using the
+// hunks of a diff generated with -U0. A line inside a replaced block maps to
+// the corresponding replacement line. A line that was removed without
+// replacement is reported as deleted, with the closest surviving new line
+// (0 if the whole file is gone).
+func mapOldLine(hunks []diffHunk, line int) (newLine int, deleted bool) {
+	offset := 0
+	for _, h := range hunks {
+		if h.OldLines > 0 && line >= h.OldStart && line < h.OldStart+h.OldLines {
+			if h.NewLines == 0 {
+				return h.NewStart, true
+			}
+			return h.NewStart + min(line-h.OldStart, h.NewLines-1), false
+		}
+		before := h.OldStart+h.OldLines <= line
+		if h.OldLines == 0 {
+			// Pure insertion after line OldStart.
+			before = h.OldStart < line
+		}
+		if !before {
+			break
+		}
+		offset += h.NewLines - h.OldLines
+	}
+	return line + offset, false
+}
+
+// mapOldSide moves old-side comments of review onto the working tree in dir,
+// based on `git diff` against the commit each comment refers to. Comments on deleted lines stay on the old
+// side and are marked as deleted. Files whose diff cannot be computed are left
+// unchanged.
+func mapOldSide(ctx context.Context, dir string, review *FormattedReview) {
+	type fileDiff struct {
+		hunks []diffHunk
+		err   error
+	}
+	cache := map[[2]string]fileDiff{}
+	for i, c := range review.Comments {
+		if !c.IsOldSide || c.Line == 0 {
+			continue
+		}
+		commit := review.oldCommit(c)
+		d, ok := cache[[2]string{commit, c.File}]
+		if !ok {
+			out, err := execute(ctx, dir, "git", "diff", "--no-color", "-U0", commit, "--", c.File)
+			d = fileDiff{hunks: parseHunks(out), err: err}
+			cache[[2]string{commit, c.File}] = d
+		}
+		if d.err != nil {
+			continue
+		}
+		newLine, deleted := mapOldLine(d.hunks, c.Line)
+		if deleted {
+			review.Comments[i].Deleted = true
+			review.Comments[i].NearLine = newLine
+			continue
+		}
+		review.Comments[i].IsOldSide = false
+		review.Comments[i].Line = newLine
+	}
+}
+
+// diffLine is a line of a file diff. Hunk headers have Kind '@' and no line
+// numbers; removed lines only have OldLine and added lines only NewLine.
+type diffLine struct {
+	Kind             byte
+	OldLine, NewLine int
+	Text             string
+}
+
+// target returns the line a comment on l refers to: the old line for removed
+// lines and the new line otherwise. Hunk headers have no target.
+func (l diffLine) target() (line int, oldSide bool) {
+	switch l.Kind {
+	case '-':
+		return l.OldLine, true
+	case '+', ' ':
+		return l.NewLine, false
+	}
+	return 0, false
+}
+
+// diffFile is the diff of a single file, using the status names of tuicr.
+type diffFile struct {
+	Path    string
+	OldPath string
+	Status  string
+	Lines   []diffLine
+}
+
+// parseDiff splits a unified git diff into files.
+func parseDiff(diff []byte) []diffFile {
+	var files []diffFile
+	var f *diffFile
+	var oldLine, newLine int
+	scanner := bufio.NewScanner(bytes.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if rest, ok := strings.CutPrefix(line, "diff --git "); ok {
+			files = append(files, diffFile{Status: "modified"})
+			f = &files[len(files)-1]
+			if a, b, ok := strings.Cut(rest, " b/"); ok {
+				f.OldPath, f.Path = strings.TrimPrefix(a, "a/"), b
+			}
+			continue
+		}
+		if f == nil {
+			continue
+		}
+		if h, ok := parseHunkHeader(line); ok {
+			oldLine, newLine = h.OldStart, h.NewStart
+			f.Lines = append(f.Lines, diffLine{Kind: '@', Text: line})
+			continue
+		}
+		if len(f.Lines) == 0 {
+			// Extended header lines before the first hunk.
+			switch {
+			case strings.HasPrefix(line, "new file mode"):
+				f.Status = "added"
+			case strings.HasPrefix(line, "deleted file mode"):
+				f.Status = "deleted"
+			case strings.HasPrefix(line, "rename from "):
+				f.Status = "renamed"
+				f.OldPath = strings.TrimPrefix(line, "rename from ")
+			case strings.HasPrefix(line, "rename to "):
+				f.Path = strings.TrimPrefix(line, "rename to ")
+			case strings.HasPrefix(line, "+++ b/"):
+				f.Path = strings.TrimPrefix(line, "+++ b/")
+			}
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		switch line[0] {
+		case ' ':
+			f.Lines = append(f.Lines, diffLine{Kind: ' ', OldLine: oldLine, NewLine: newLine, Text: line[1:]})
+			oldLine++
+			newLine++
+		case '-':
+			f.Lines = append(f.Lines, diffLine{Kind: '-', OldLine: oldLine, Text: line[1:]})
+			oldLine++
+		case '+':
+			f.Lines = append(f.Lines, diffLine{Kind: '+', NewLine: newLine, Text: line[1:]})
+			newLine++
+		}
+	}
+	return files
+}
+
+// loadDiff returns the changes of the working tree in dir against base.
+// Untracked files are not part of the diff.
+func loadDiff(ctx context.Context, dir, base string) ([]diffFile, error) {
+	out, err := execute(ctx, dir, "git", "diff", "--no-color", "--no-ext-diff", base, "--")
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s: %w: %s", base, err, bytes.TrimSpace(out))
+	}
+	return parseDiff(out), nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHunks(t *testing.T) {
+	diff := "diff --git a/f b/f\n--- a/f\n+++ b/f\n@@ -2 +1,0 @@\n-b\n@@ -5,0 +5,2 @@ func x()\n+y\n+z\n@@ -8,2 +9 @@\n-h\n-i\n+hi\n"
+	require.Equal(t, []diffHunk{
+		{OldStart: 2, OldLines: 1, NewStart: 1, NewLines: 0},
+		{OldStart: 5, OldLines: 0, NewStart: 5, NewLines: 2},
+		{OldStart: 8, OldLines: 2, NewStart: 9, NewLines: 1},
+	}, parseHunks([]byte(diff)))
+}
+
+func TestMapOldLine(t *testing.T) {
+	hunks := []diffHunk{
+		{OldStart: 2, OldLines: 1, NewStart: 1, NewLines: 0},
+		{OldStart: 5, OldLines: 0, NewStart: 5, NewLines: 2},
+		{OldStart: 8, OldLines: 2, NewStart: 9, NewLines: 1},
+	}
+	cases := []struct {
+		old, new int
+		deleted  bool
+	}{
+		{1, 1, false},
+		{2, 1, true},
+		{3, 2, false},
+		{5, 4, false},
+		{6, 7, false},
+		{8, 9, false},
+		{9, 9, false},
+		{10, 10, false},
+	}
+	for _, tc := range cases {
+		got, deleted := mapOldLine(hunks, tc.old)
+		require.Equal(t, tc.new, got, "old line %d", tc.old)
+		require.Equal(t, tc.deleted, deleted, "old line %d", tc.old)
+	}
+}
+
+func TestMapOldSide(t *testing.T) {
+	_, local := setupBareRepo(t)
+	ctx := context.Background()
+
+	file := filepath.Join(local, "code.txt")
+	require.NoError(t, os.WriteFile(file, []byte("a\nb\nc\nd\n"), 0644))
+	_, err := exec.Command("git", "-C", local, "add", ".").CombinedOutput()
+	require.NoError(t, err)
+	_, err = exec.Command("git", "-C", local, "commit", "-m", "code").CombinedOutput()
+	require.NoError(t, err)
+	head, err := exec.Command("git", "-C", local, "rev-parse", "HEAD").Output()
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(file, []byte("new\na\nc\nd\n"), 0644))
+
+	review := &FormattedReview{
+		CommitSha: strings.TrimSpace(string(head)),
+		Comments: []FormattedComment{
+			{File: "code.txt", Line: 3, IsOldSide: true, Content: "moved"},
+			{File: "code.txt", Line: 2, IsOldSide: true, Content: "removed"},
+			{File: "code.txt", Line: 3, Content: "new side"},
+			{File: "README.md", Line: 1, IsOldSide: true, Content: "unchanged"},
+		},
+	}
+	mapOldSide(ctx, local, review)
+
+	require.Equal(t, FormattedComment{File: "code.txt", Line: 3, Content: "moved"}, review.Comments[0])
+	require.True(t, review.Comments[1].Deleted)
+	require.True(t, review.Comments[1].IsOldSide)
+	require.Equal(t, "`code.txt:~2` (deleted, near line 2)", review.Comments[1].Location())
+	require.Equal(t, FormattedComment{File: "code.txt", Line: 3, Content: "new side"}, review.Comments[2])
+	require.Equal(t, "`README.md:1`", review.Comments[3].Location())
+}
+
+func TestMapOldSide_oldCommit(t *testing.T) {
+	_, local := setupBareRepo(t)
+	ctx := context.Background()
+	base, err := revParse(ctx, local, "HEAD")
+	require.NoError(t, err)
+
+	// The pull request head inserts two lines above the README's only line.
+	file := filepath.Join(local, "README.md")
+	require.NoError(t, os.WriteFile(file, []byte("x\ny\nhello"), 0644))
+	out, err := exec.Command("git", "-C", local, "commit", "-qam", "head").CombinedOutput()
+	require.NoError(t, err, string(out))
+	head, err := revParse(ctx, local, "HEAD")
+	require.NoError(t, err)
+
+	review := &FormattedReview{
+		CommitSha: head,
+		Comments:  []FormattedComment{{File: "README.md", Line: 1, IsOldSide: true, OldCommit: base, Content: "greeting"}},
+	}
+	mapOldSide(ctx, local, review)
+	require.Equal(t, "`README.md:3`", review.Comments[0].Location())
+
+	embedContext(ctx, local, review, 0)
+	require.Equal(t, "> 3 | hello\n", review.Comments[0].Context)
+}
+
+func TestParseDiff(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\nindex 1..2 100644\n--- a/a.go\n+++ b/a.go\n@@ -1,3 +1,3 @@ package a\n a\n-b\n+B\n c\n" +
+		"diff --git a/new.go b/new.go\nnew file mode 100644\n--- /dev/null\n+++ b/new.go\n@@ -0,0 +1 @@\n+x\n" +
+		"diff --git a/old.go b/old.go\ndeleted file mode 100644\n--- a/old.go\n+++ /dev/null\n@@ -1 +0,0 @@\n-y\n\\ No newline at end of file\n" +
+		"diff --git a/x.go b/y.go\nsimilarity index 100%\nrename from x.go\nrename to y.go\n"
+	files := parseDiff([]byte(diff))
+	require.Len(t, files, 4)
+
+	require.Equal(t, "a.go", files[0].Path)
+	require.Equal(t, "modified", files[0].Status)
+	require.Equal(t, []diffLine{
+		{Kind: '@', Text: "@@ -1,3 +1,3 @@ package a"},
+		{Kind: ' ', OldLine: 1, NewLine: 1, Text: "a"},
+		{Kind: '-', OldLine: 2, Text: "b"},
+		{Kind: '+', NewLine: 2, Text: "B"},
+		{Kind: ' ', OldLine: 3, NewLine: 3, Text: "c"},
+	}, files[0].Lines)
+
+	require.Equal(t, "added", files[1].Status)
+	require.Equal(t, []diffLine{{Kind: '@', Text: "@@ -0,0 +1 @@"}, {Kind: '+', NewLine: 1, Text: "x"}}, files[1].Lines)
+
+	require.Equal(t, "old.go", files[2].Path)
+	require.Equal(t, "deleted", files[2].Status)
+	require.Len(t, files[2].Lines, 2)
+
+	require.Equal(t, diffFile{Path: "y.go", OldPath: "x.go", Status: "renamed"}, files[3])
+}
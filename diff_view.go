@@ -0,0 +1,481 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// commentTypes lists the comment types offered by the diff viewer.
+var commentTypes = []string{"issue", "suggestion", "note", "praise"}
+
+type diffLoadedMsg struct {
+	project  *Project
+	previous *ReviewRecord
+	base     string
+	files    []diffFile
+	err      error
+}
+
+type diffReviewDoneMsg struct {
+	project  *Project
+	previous *ReviewRecord
+	review   *FormattedReview
+}
+
+type diffReviewClosedMsg struct{}
+
+// loadDiffCmd loads the changes of p against base for review in the diff viewer.
+func loadDiffCmd(p *Project, base string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		prev, err := previousRound(p, p.branch)
+		if err != nil {
+			return diffLoadedMsg{project: p, err: err}
+		}
+		sha, err := revParse(ctx, p.path, base)
+		if err != nil {
+			return diffLoadedMsg{project: p, err: fmt.Errorf("resolve %s: %w", base, err)}
+		}
+		files, err := loadDiff(ctx, p.path, sha)
+		if err == nil && len(files) == 0 {
+			err = fmt.Errorf("no changes against %s", base)
+		}
+		return diffLoadedMsg{project: p, previous: prev, base: sha, files: files, err: err}
+	}
+}
+
+var (
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	diffHunkStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	diffCursorStyle  = lipgloss.NewStyle().Reverse(true)
+	diffCommentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+)
+
+type diffKeyMap struct {
+	Up          key.Binding
+	Down        key.Binding
+	NextHunk    key.Binding
+	PrevHunk    key.Binding
+	NextFile    key.Binding
+	PrevFile    key.Binding
+	Comment     key.Binding
+	FileComment key.Binding
+	Delete      key.Binding
+	DeleteFile  key.Binding
+	Reviewed    key.Binding
+	Submit      key.Binding
+	Cancel      key.Binding
+}
+
+func defaultDiffKeyMap() diffKeyMap {
+	return diffKeyMap{
+		Up:          key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+		Down:        key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+		NextHunk:    key.NewBinding(key.WithKeys("]"), key.WithHelp("]", "next hunk")),
+		PrevHunk:    key.NewBinding(key.WithKeys("["), key.WithHelp("[", "prev hunk")),
+		NextFile:    key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "next file")),
+		PrevFile:    key.NewBinding(key.WithKeys("shift+tab"), key.WithHelp("shift+tab", "prev file")),
+		Comment:     key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "comment")),
+		FileComment: key.NewBinding(key.WithKeys("C"), key.WithHelp("C", "file comment")),
+		Delete:      key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "delete comment")),
+		DeleteFile:  key.NewBinding(key.WithKeys("X"), key.WithHelp("X", "delete file comment")),
+		Reviewed:    key.NewBinding(key.WithKeys("m"), key.WithHelp("m", "mark reviewed")),
+		Submit:      key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "submit")),
+		Cancel:      key.NewBinding(key.WithKeys("esc", "q"), key.WithHelp("esc/q", "cancel")),
+	}
+}
+
+func (k diffKeyMap) help() []key.Binding {
+	return []key.Binding{k.NextHunk, k.PrevHunk, k.NextFile, k.Comment, k.FileComment, k.Delete, k.DeleteFile, k.Reviewed, k.Submit, k.Cancel}
+}
+
+// DiffView reviews the diff of a project against a base commit and turns the
+// comments attached to its lines into a FormattedReview.
+type DiffView struct {
+	project  *Project
+	previous *ReviewRecord
+	base     string
+	files    []diffFile
+	reviewed []bool
+	comments []FormattedComment
+
+	file   int
+	cursor int
+	offset int
+
+	editing     bool
+	fileComment bool
+	commentType int
+	input       textinput.Model
+
+	err    error
+	keyMap diffKeyMap
+	width  int
+	height int
+}
+
+func NewDiffView(p *Project, previous *ReviewRecord, base string, files []diffFile, width, height int) *DiffView {
+	input := textinput.New()
+	input.Placeholder = "comment"
+	v := &DiffView{
+		project:  p,
+		previous: previous,
+		base:     base,
+		files:    files,
+		reviewed: make([]bool, len(files)),
+		input:    input,
+		keyMap:   defaultDiffKeyMap(),
+		width:    width,
+		height:   height,
+	}
+	v.selectFile(0)
+	return v
+}
+
+func (v *DiffView) Init() tea.Cmd { return nil }
+
+func (v *DiffView) lines() []diffLine {
+	if len(v.files) == 0 {
+		return nil
+	}
+	return v.files[v.file].Lines
+}
+
+// cursorLine returns the line under the cursor, or the zero line for files
+// without textual changes.
+func (v *DiffView) cursorLine() diffLine {
+	lines := v.lines()
+	if v.cursor >= len(lines) {
+		return diffLine{}
+	}
+	return lines[v.cursor]
+}
+
+// nextCommentable returns the first line from i in direction dir that is not
+// a hunk header.
+func (v *DiffView) nextCommentable(i, dir int) (int, bool) {
+	lines := v.lines()
+	for j := i; j >= 0 && j < len(lines); j += dir {
+		if lines[j].Kind != '@' {
+			return j, true
+		}
+	}
+	return 0, false
+}
+
+func (v *DiffView) moveCursor(dir int) {
+	if j, ok := v.nextCommentable(v.cursor+dir, dir); ok {
+		v.cursor = j
+	}
+}
+
+// jumpHunk moves the cursor to the first line of the next or previous hunk.
+func (v *DiffView) jumpHunk(dir int) {
+	lines := v.lines()
+	start := v.cursor
+	if dir < 0 {
+		// Skip back over the header of the current hunk first.
+		for start >= 0 && start < len(lines) && lines[start].Kind != '@' {
+			start--
+		}
+	}
+	for j := start + dir; j >= 0 && j < len(lines); j += dir {
+		if lines[j].Kind != '@' {
+			continue
+		}
+		if k, ok := v.nextCommentable(j, 1); ok {
+			v.cursor = k
+		}
+		return
+	}
+}
+
+func (v *DiffView) selectFile(i int) {
+	if len(v.files) == 0 {
+		return
+	}
+	v.file = (i + len(v.files)) % len(v.files)
+	v.cursor, _ = v.nextCommentable(0, 1)
+	v.offset = 0
+}
+
+func (v *DiffView) startComment(fileComment bool) tea.Cmd {
+	if len(v.files) == 0 {
+		return nil
+	}
+	if !fileComment {
+		if line, _ := v.cursorLine().target(); line == 0 {
+			return nil
+		}
+	}
+	v.editing = true
+	v.fileComment = fileComment
+	v.input.Reset()
+	v.updatePrompt()
+	return v.input.Focus()
+}
+
+func (v *DiffView) saveComment() {
+	v.editing = false
+	v.input.Blur()
+	content := strings.TrimSpace(v.input.Value())
+	if content == "" {
+		return
+	}
+	c := FormattedComment{
+		File:    v.files[v.file].Path,
+		Type:    commentTypes[v.commentType],
+		Content: content,
+		Index:   len(v.comments),
+	}
+	if !v.fileComment {
+		c.Line, c.IsOldSide = v.cursorLine().target()
+	}
+	v.comments = append(v.comments, c)
+}
+
+// commentsAt returns the indexes of the comments on line of the current file;
+// line 0 selects the file comments.
+func (v *DiffView) commentsAt(line int, oldSide bool) []int {
+	var idx []int
+	for i, c := range v.comments {
+		if c.File == v.files[v.file].Path && c.Line == line && (line == 0 || c.IsOldSide == oldSide) {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// deleteComment removes the latest comment on the cursor line or, for
+// fileComment, the latest comment on the current file.
+func (v *DiffView) deleteComment(fileComment bool) {
+	line, oldSide := v.cursorLine().target()
+	if fileComment {
+		line = 0
+	} else if line == 0 {
+		return
+	}
+	idx := v.commentsAt(line, oldSide)
+	if len(idx) == 0 {
+		return
+	}
+	v.comments = slices.Delete(v.comments, idx[len(idx)-1], idx[len(idx)-1]+1)
+}
+
+// Review returns the comments as a FormattedReview against the base commit.
+// Comments are indexed in the order they were written.
+func (v *DiffView) Review() *FormattedReview {
+	review := &FormattedReview{CommitSha: v.base}
+	for i, f := range v.files {
+		review.Files = append(review.Files, ReviewFile{Path: f.Path, Status: f.Status, Reviewed: v.reviewed[i]})
+	}
+	for i, c := range v.comments {
+		c.Index = i
+		review.Comments = append(review.Comments, c)
+	}
+	return review
+}
+
+func (v *DiffView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := v.update(msg)
+	v.scroll()
+	return model, cmd
+}
+
+func (v *DiffView) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.width, v.height = msg.Width, msg.Height
+		return v, nil
+	case tea.KeyMsg:
+		if v.editing {
+			switch msg.String() {
+			case "enter":
+				v.saveComment()
+				return v, nil
+			case "esc":
+				v.editing = false
+				v.input.Blur()
+				return v, nil
+			case "tab":
+				v.commentType = (v.commentType + 1) % len(commentTypes)
+				v.updatePrompt()
+				return v, nil
+			}
+			var cmd tea.Cmd
+			v.input, cmd = v.input.Update(msg)
+			return v, cmd
+		}
+		if len(v.files) == 0 {
+			if key.Matches(msg, v.keyMap.Cancel) {
+				return v, func() tea.Msg { return diffReviewClosedMsg{} }
+			}
+			return v, nil
+		}
+		v.err = nil
+		switch {
+		case key.Matches(msg, v.keyMap.Up):
+			v.moveCursor(-1)
+		case key.Matches(msg, v.keyMap.Down):
+			v.moveCursor(1)
+		case key.Matches(msg, v.keyMap.NextHunk):
+			v.jumpHunk(1)
+		case key.Matches(msg, v.keyMap.PrevHunk):
+			v.jumpHunk(-1)
+		case key.Matches(msg, v.keyMap.NextFile):
+			v.selectFile(v.file + 1)
+		case key.Matches(msg, v.keyMap.PrevFile):
+			v.selectFile(v.file - 1)
+		case key.Matches(msg, v.keyMap.Comment):
+			return v, v.startComment(false)
+		case key.Matches(msg, v.keyMap.FileComment):
+			return v, v.startComment(true)
+		case key.Matches(msg, v.keyMap.Delete):
+			v.deleteComment(false)
+		case key.Matches(msg, v.keyMap.DeleteFile):
+			v.deleteComment(true)
+		case key.Matches(msg, v.keyMap.Reviewed):
+			v.reviewed[v.file] = !v.reviewed[v.file]
+		case key.Matches(msg, v.keyMap.Submit):
+			if len(v.comments) == 0 {
+				v.err = fmt.Errorf("add a comment before submitting")
+				return v, nil
+			}
+			done := diffReviewDoneMsg{project: v.project, previous: v.previous, review: v.Review()}
+			return v, func() tea.Msg { return done }
+		case key.Matches(msg, v.keyMap.Cancel):
+			return v, func() tea.Msg { return diffReviewClosedMsg{} }
+		}
+	}
+	return v, nil
+}
+
+func (v *DiffView) renderLine(l diffLine) string {
+	num := func(n int) string {
+		if n == 0 {
+			return "    "
+		}
+		return fmt.Sprintf("%4d", n)
+	}
+	text := strings.ReplaceAll(l.Text, "\t", "    ")
+	switch l.Kind {
+	case '@':
+		return diffHunkStyle.Render(text)
+	case '+':
+		return diffAddedStyle.Render(fmt.Sprintf("%s %s +%s", num(0), num(l.NewLine), text))
+	case '-':
+		return diffRemovedStyle.Render(fmt.Sprintf("%s %s -%s", num(l.OldLine), num(0), text))
+	}
+	return fmt.Sprintf("%s %s  %s", num(l.OldLine), num(l.NewLine), text)
+}
+
+func (v *DiffView) renderComment(c FormattedComment) string {
+	return diffCommentStyle.Render(fmt.Sprintf("          └ [%s] %s", strings.ToUpper(c.Type), c.Content))
+}
+
+// rows renders the current file with its comments, one row per line, and
+// returns the row of the cursor.
+func (v *DiffView) rows() (rows []string, cursorRow int) {
+	f := v.files[v.file]
+	for _, i := range v.commentsAt(0, false) {
+		rows = append(rows, v.renderComment(v.comments[i]))
+	}
+	if len(f.Lines) == 0 {
+		rows = append(rows, "(no textual changes)")
+	}
+	for i, l := range f.Lines {
+		row := v.renderLine(l)
+		if i == v.cursor {
+			cursorRow = len(rows)
+			row = diffCursorStyle.Render(row)
+		}
+		rows = append(rows, row)
+		if line, oldSide := l.target(); line > 0 {
+			for _, j := range v.commentsAt(line, oldSide) {
+				rows = append(rows, v.renderComment(v.comments[j]))
+			}
+		}
+	}
+	return rows, cursorRow
+}
+
+// bodyHeight is the number of rows of the diff shown at once.
+func (v *DiffView) bodyHeight() int { return max(v.height-5, 1) }
+
+// scroll keeps the cursor row within the visible rows.
+func (v *DiffView) scroll() {
+	if len(v.files) == 0 {
+		return
+	}
+	_, cursorRow := v.rows()
+	if body := v.bodyHeight(); cursorRow < v.offset {
+		v.offset = cursorRow
+	} else if cursorRow >= v.offset+body {
+		v.offset = cursorRow - body + 1
+	}
+}
+
+func (v *DiffView) View() string {
+	var s strings.Builder
+	if len(v.files) == 0 {
+		return outputTitleStyle.Render("Diff review") + "\n\nNo changes.\n"
+	}
+	f := v.files[v.file]
+	title := fmt.Sprintf("%s – %s (%s) · file %d/%d · %d comments", v.project.Title(), f.Path, f.Status, v.file+1, len(v.files), len(v.comments))
+	if v.reviewed[v.file] {
+		title += " · reviewed"
+	}
+	s.WriteString(outputTitleStyle.Render(title) + "\n\n")
+
+	rows, _ := v.rows()
+	body := v.bodyHeight()
+	end := min(v.offset+body, len(rows))
+	clip := lipgloss.NewStyle().MaxWidth(max(v.width, 1))
+	for _, row := range rows[min(v.offset, end):end] {
+		s.WriteString(clip.Render(row) + "\n")
+	}
+	for range body - (end - min(v.offset, end)) {
+		s.WriteString("\n")
+	}
+
+	switch {
+	case v.editing:
+		s.WriteString(v.input.View() + "\n")
+		s.WriteString(outputHelpStyle.Render("enter save • tab type • esc cancel"))
+	case v.err != nil:
+		s.WriteString(diffRemovedStyle.Render(v.err.Error()) + "\n")
+		s.WriteString(v.helpView())
+	default:
+		s.WriteString("\n" + v.helpView())
+	}
+	return s.String()
+}
+
+func (v *DiffView) updatePrompt() {
+	f := v.files[v.file]
+	target := f.Path
+	if !v.fileComment {
+		line, oldSide := v.cursorLine().target()
+		target = fmt.Sprintf("%s:%d", f.Path, line)
+		if oldSide {
+			target += " (old)"
+		}
+	}
+	v.input.Prompt = fmt.Sprintf("[%s] %s: ", strings.ToUpper(commentTypes[v.commentType]), target)
+}
+
+func (v *DiffView) helpView() string {
+	var parts []string
+	for _, b := range v.keyMap.help() {
+		parts = append(parts, b.Help().Key+" "+b.Help().Desc)
+	}
+	return outputHelpStyle.Render(strings.Join(parts, " • "))
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func typeKeys(v *DiffView, keys ...string) {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "tab":
+			msg = tea.KeyMsg{Type: tea.KeyTab}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		v.Update(msg)
+	}
+}
+
+func TestDiffView_Review(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n@@ -10 +10 @@\n-j\n+J\n" +
+		"diff --git a/b.go b/b.go\nnew file mode 100644\n--- /dev/null\n+++ b/b.go\n@@ -0,0 +1 @@\n+x\n"
+	p := &Project{owner: "o", repo: "r", branch: "main"}
+	v := NewDiffView(p, nil, "abc", parseDiff([]byte(diff)), 80, 20)
+
+	// The cursor starts on the first line after the hunk header.
+	require.Equal(t, 1, v.cursor)
+	typeKeys(v, "j", "c", "w", "h", "y", "enter")
+	typeKeys(v, "j", "c", "tab", "n", "i", "c", "e", "enter")
+	typeKeys(v, "]", "c", "tab", "tab", "o", "k", "enter", "x")
+	// The comment type sticks until changed.
+	typeKeys(v, "tab", "C", "g", "o", "o", "d", "enter", "m")
+	typeKeys(v, "c", "esc")
+	require.Contains(t, v.View(), "[PRAISE] good")
+
+	_, cmd := v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	require.NotNil(t, cmd)
+	msg, ok := cmd().(diffReviewDoneMsg)
+	require.True(t, ok)
+
+	review := msg.review
+	require.Equal(t, "abc", review.CommitSha)
+	require.Equal(t, []ReviewFile{
+		{Path: "a.go", Status: "modified"},
+		{Path: "b.go", Status: "added", Reviewed: true},
+	}, review.Files)
+	require.Equal(t, []FormattedComment{
+		{File: "a.go", Line: 2, IsOldSide: true, Type: "issue", Content: "why", Index: 0},
+		{File: "a.go", Line: 2, Type: "suggestion", Content: "nice", Index: 1},
+		{File: "b.go", Type: "praise", Content: "good", Index: 2},
+	}, review.Comments)
+}
+
+func TestDiffView_submitRequiresComment(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b\n"
+	v := NewDiffView(&Project{}, nil, "abc", parseDiff([]byte(diff)), 80, 20)
+	_, cmd := v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	require.Nil(t, cmd)
+	require.Error(t, v.err)
+}
+
+func TestDiffView_deleteFileComment(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b\n"
+	v := NewDiffView(&Project{}, nil, "abc", parseDiff([]byte(diff)), 80, 20)
+	typeKeys(v, "C", "f", "enter", "c", "l", "enter")
+	require.Len(t, v.comments, 2)
+
+	typeKeys(v, "X")
+	require.Equal(t, []FormattedComment{{File: "a.go", Line: 1, IsOldSide: true, Type: "issue", Content: "l", Index: 1}}, v.comments)
+	typeKeys(v, "X")
+	require.Len(t, v.comments, 1, "line comments are kept")
+}
+
+func TestDiffView_scroll(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1,20 +1,20 @@\n"
+	for range 20 {
+		diff += " x\n"
+	}
+	v := NewDiffView(&Project{}, nil, "abc", parseDiff([]byte(diff)), 80, 10)
+	for range 10 {
+		typeKeys(v, "j")
+	}
+	require.Equal(t, 11, v.cursor)
+	require.Equal(t, 7, v.offset)
+
+	v.View()
+	require.Equal(t, 7, v.offset, "rendering does not scroll")
+	v.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	require.Equal(t, 7, v.offset)
+	for range 10 {
+		typeKeys(v, "k")
+	}
+	require.Equal(t, 1, v.offset)
+}
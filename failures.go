@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FeedbackConfig controls sending the failures of a project's commands back
+// to the agent after it changed the project.
+type FeedbackConfig struct {
+	// Auto queues a follow-up job with the failures after each agent job.
+	Auto bool `yaml:"auto"`
+	// Checks are the kinds of commands run after an agent job.
+	Checks []string `yaml:"checks,omitempty"`
+	// MaxIterations bounds the follow-up jobs queued for a review.
+	MaxIterations int `yaml:"max_iterations"`
+}
+
+var defaultFeedbackConfig = FeedbackConfig{Checks: []string{"test"}, MaxIterations: 3}
+
+const (
+	feedbackSource = "feedback"
+	// failureOutputLines is the number of trailing lines of output sent for
+	// a failure without a location.
+	failureOutputLines = 40
+	// maxFailureLines bounds the lines of a single failure message.
+	maxFailureLines = 20
+)
+
+var (
+	// failureLocationRE matches file:line[:col]: message as printed by go
+	// test, go vet, compilers and most linters.
+	failureLocationRE = regexp.MustCompile(`^(\s*)([^\s:]+\.[A-Za-z0-9]+):(\d+)(?::(\d+))?:\s*(.*)$`)
+	// goPackageRE matches the lines of go test and go vet naming a package.
+	goPackageRE = regexp.MustCompile(`^(?:(?:FAIL|ok)\s+|# )(\S+)`)
+)
+
+// parseFailures turns the locations in the output of a failed command run in
+// dir into ISSUE comments, with paths relative to dir.
+func parseFailures(dir, command, output string) []FormattedComment {
+	var (
+		comments []FormattedComment
+		// pending holds the comments whose package is named after them, as
+		// go test does.
+		pending []int
+		pkg     string
+		indent  = -1
+	)
+	resolve := func(pkgDir string) {
+		for _, i := range pending {
+			comments[i].File = resolveFailureFile(dir, pkgDir, comments[i].File)
+		}
+		pending = nil
+	}
+	modulePath := goModulePath(dir)
+	sc := bufio.NewScanner(strings.NewReader(output))
+	sc.Buffer(nil, 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if m := failureLocationRE.FindStringSubmatch(line); m != nil {
+			indent = len(m[1])
+			msg := m[5]
+			if m[4] != "" {
+				msg = fmt.Sprintf("column %s: %s", m[4], msg)
+			}
+			lineNo, _ := strconv.Atoi(m[3])
+			comments = append(comments, FormattedComment{
+				File:    m[2],
+				Line:    lineNo,
+				Type:    "issue",
+				Content: fmt.Sprintf("Reported by `%s`: %s", command, msg),
+			})
+			pending = append(pending, len(comments)-1)
+			continue
+		}
+		if m := goPackageRE.FindStringSubmatch(line); m != nil {
+			indent = -1
+			pkg = strings.TrimPrefix(strings.TrimPrefix(m[1], modulePath), "/")
+			if strings.HasPrefix(line, "#") {
+				// go vet and the compiler name the package before its errors.
+				resolve("")
+			} else {
+				resolve(pkg)
+				pkg = ""
+			}
+			continue
+		}
+		// Lines indented deeper than a location continue its message.
+		trimmed := strings.TrimLeft(line, " \t")
+		if indent >= 0 && trimmed != "" && len(line)-len(trimmed) > indent && len(comments) > 0 {
+			c := &comments[len(comments)-1]
+			if strings.Count(c.Content, "\n") < maxFailureLines {
+				c.Content += "\n" + strings.TrimSpace(trimmed)
+			}
+			continue
+		}
+		indent = -1
+	}
+	resolve(pkg)
+
+	// Drop repeated failures, e.g. of a package built for several tests.
+	seen := map[string]bool{}
+	comments = slices.DeleteFunc(comments, func(c FormattedComment) bool {
+		key := fmt.Sprintf("%s:%d:%s", c.File, c.Line, c.Content)
+		dup := seen[key]
+		seen[key] = true
+		return dup
+	})
+	for i := range comments {
+		comments[i].Index = i
+	}
+	return comments
+}
+
+// goModulePath returns the module path of the Go module at dir, if any.
+func goModulePath(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(path), `"`)
+		}
+	}
+	return ""
+}
+
+// resolveFailureFile returns file relative to dir. Go test reports files
+// relative to their package directory pkgDir; a file found nowhere else is
+// looked up by its name. Unknown files are returned unchanged.
+func resolveFailureFile(dir, pkgDir, file string) string {
+	exists := func(path string) bool {
+		info, err := os.Stat(path)
+		return err == nil && !info.IsDir()
+	}
+	if filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(dir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+		return file
+	}
+	for _, rel := range []string{filepath.Join(pkgDir, file), file} {
+		if exists(filepath.Join(dir, rel)) {
+			return filepath.ToSlash(filepath.Clean(rel))
+		}
+	}
+	var found []string
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && path != dir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || d.Name() == "vendor") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(filepath.ToSlash(path), "/"+filepath.ToSlash(file)) {
+			found = append(found, path)
+		}
+		return nil
+	})
+	if len(found) == 1 {
+		rel, _ := filepath.Rel(dir, found[0])
+		return filepath.ToSlash(rel)
+	}
+	return file
+}
+
+// failureReview turns the failed results into a review for the agent. A
+// failure without a location is sent as a general comment naming the command
+// with the tail of its output. If the agent's change was reverted, the comments of
+// reverted are sent again along with the failures. It returns nil if all
+// results passed.
+func failureReview(dir string, results []*CheckResult, reverted *ReviewRecord) *FormattedReview {
+	review := &FormattedReview{Source: feedbackSource}
+	var commands []string
+	for _, r := range results {
+		if r.Passed {
+			continue
+		}
+		commands = append(commands, "`"+r.Command+"`")
+		comments := parseFailures(dir, r.Command, r.Output)
+		if len(comments) == 0 {
+			comments = []FormattedComment{{
+				Type:    "issue",
+				Content: fmt.Sprintf("`%s` failed with exit code %d:\n```\n%s\n```", r.Command, r.ExitCode, tail(r.Output, failureOutputLines)),
+			}}
+		}
+		review.Comments = append(review.Comments, comments...)
+	}
+	if len(review.Comments) == 0 {
+		return nil
+	}
+	review.Notes = fmt.Sprintf("%s failed after your last change. Fix the failures.", strings.Join(commands, " and "))
+	if reverted != nil {
+		review.Notes = fmt.Sprintf("Your change for the comments below was reverted because %s failed. Address the comments again without causing the failures.", strings.Join(commands, " and "))
+		review.Comments = append(slices.Clone(reverted.Comments), review.Comments...)
+		if reverted.Notes != "" {
+			review.Notes += "\n\n" + reverted.Notes
+		}
+	}
+	for i := range review.Comments {
+		review.Comments[i].Index = i
+	}
+	return review
+}
+
+// followUpReview runs the feedback checks after an agent job on r and
+// records a review of their failures to be sent to the agent. It returns nil
+// if automatic feedback is off, the checks pass or the follow-ups of r are
+// used up.
+func followUpReview(ctx context.Context, p *Project, r *ReviewRecord, tcr *TCRResult) (*ReviewRecord, error) {
+	fc := p.Config().Feedback
+	if !fc.Auto || r.Iteration >= fc.MaxIterations {
+		return nil, nil
+	}
+	var (
+		results  []*CheckResult
+		reverted *ReviewRecord
+	)
+	if tcr != nil && tcr.Tested && !tcr.Passed {
+		// The change is gone; only the test run that caused it is relevant.
+		test, err := p.checkStore().Load("test")
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, test)
+		reverted = r
+	} else {
+		for _, kind := range fc.Checks {
+			if p.Commands().command(kind) == "" {
+				continue
+			}
+			result, err := runCheck(ctx, p, kind)
+			if result == nil {
+				return nil, err
+			}
+			results = append(results, result)
+		}
+	}
+	review := failureReview(p.path, results, reverted)
+	if review == nil {
+		return nil, nil
+	}
+	record, err := newReviewRecord(ctx, p, review, nil)
+	if err != nil {
+		return nil, err
+	}
+	record.Iteration = r.Iteration + 1
+	return record, p.SaveReview(record)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFailures(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":          "module example.com/m\n",
+		"pkg/a_test.go":   "package pkg\n",
+		"cmd/main.go":     "package main\n",
+		"util/strings.go": "package util\n",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	output := `--- FAIL: TestA (0.00s)
+    a_test.go:12: got 1
+        want 2
+FAIL
+FAIL	example.com/m/pkg	0.003s
+# example.com/m/cmd
+./cmd/main.go:5:2: undefined: foo
+cmd/main.go:5:2: undefined: foo
+strings.go:7: exported function Trim should have comment
+` + filepath.Join(dir, "pkg", "a_test.go") + `:3: absolute
+`
+	comments := parseFailures(dir, "go test ./...", output)
+	require.Equal(t, []FormattedComment{
+		{File: "pkg/a_test.go", Line: 12, Type: "issue", Content: "Reported by `go test ./...`: got 1\nwant 2", Index: 0},
+		{File: "cmd/main.go", Line: 5, Type: "issue", Content: "Reported by `go test ./...`: column 2: undefined: foo", Index: 1},
+		{File: "util/strings.go", Line: 7, Type: "issue", Content: "Reported by `go test ./...`: exported function Trim should have comment", Index: 2},
+		{File: "pkg/a_test.go", Line: 3, Type: "issue", Content: "Reported by `go test ./...`: absolute", Index: 3},
+	}, comments)
+
+	require.Empty(t, parseFailures(dir, "make", "make: *** [test] Error 1\n"))
+}
+
+func TestFailureReview(t *testing.T) {
+	dir := t.TempDir()
+	results := []*CheckResult{
+		{Kind: "test", Command: "make test", Passed: true},
+		{Kind: "lint", Command: "make lint", ExitCode: 2, Output: "one\ntwo\n"},
+	}
+	review := failureReview(dir, results, nil)
+	require.Equal(t, feedbackSource, review.Source)
+	require.Equal(t, "`make lint` failed after your last change. Fix the failures.", review.Notes)
+	require.Equal(t, []FormattedComment{
+		{Type: "issue", Content: "`make lint` failed with exit code 2:\n```\none\ntwo\n```"},
+	}, review.Comments)
+	require.Contains(t, review.String(), "1. **[ISSUE]** (general):\n`make lint` failed with exit code 2:")
+
+	reverted := &ReviewRecord{Notes: "Be brief.", Comments: []FormattedComment{{File: "a.go", Line: 1, Type: "issue", Content: "fix", Index: 0}}}
+	review = failureReview(dir, results, reverted)
+	require.Len(t, review.Comments, 2)
+	require.Equal(t, "a.go", review.Comments[0].File)
+	require.Equal(t, 1, review.Comments[1].Index)
+	require.Contains(t, review.Notes, "was reverted because `make lint` failed")
+	require.Contains(t, review.Notes, "Be brief.")
+
+	require.Nil(t, failureReview(dir, results[:1], nil))
+}
+
+func TestJobQueue_followUp(t *testing.T) {
+	p := testProject(t, agentScript(`echo fixed`))
+	p.config.Commands.Test = `echo "main.go:3: still broken"; exit 1`
+	p.config.Feedback = FeedbackConfig{Auto: true, Checks: []string{"test"}, MaxIterations: 1}
+	q := NewJobQueue()
+	events, unsubscribe := q.Subscribe()
+	defer unsubscribe()
+
+	record := &ReviewRecord{ID: "r1", Prompt: "fix it", Comments: []FormattedComment{{File: "main.go", Line: 3, Type: "issue", Content: "broken"}}}
+	require.NoError(t, p.SaveReview(record))
+	job, err := q.Enqueue(p, record)
+	require.NoError(t, err)
+
+	done := waitForJob(t, events, job.ID)
+	require.Equal(t, JobSucceeded, done.Status)
+	require.NotEmpty(t, done.FollowUp)
+	followUp, err := p.reviewStore().Load(done.FollowUp)
+	require.NoError(t, err)
+	require.Equal(t, 1, followUp.Iteration)
+	require.Equal(t, feedbackSource, followUp.Source)
+	require.Contains(t, followUp.Prompt, "Reported by `echo \"main.go:3: still broken\"; exit 1`: still broken")
+
+	jobs, err := q.Jobs(p)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	require.Equal(t, done.FollowUp, jobs[0].ReviewID)
+	last := waitForJob(t, events, jobs[0].ID)
+	require.Empty(t, last.FollowUp, "no follow-ups beyond max_iterations")
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ReviewFormatter renders a FormattedReview for a particular consumer.
+type ReviewFormatter interface {
+	Format(w io.Writer, review *FormattedReview) error
+}
+
+const defaultReviewFormat = "markdown"
+
+var reviewFormatters = map[string]ReviewFormatter{
+	"markdown": promptFormatter{defaultPrompt},
+	"tuicr":    promptFormatter{tuicrExport},
+	"json":     jsonFormatter{},
+	"sarif":    sarifFormatter{},
+}
+
+// reviewFormats returns the names of the available review formats.
+func reviewFormats() []string { return slices.Sorted(maps.Keys(reviewFormatters)) }
+
+func formatterFor(name string) (ReviewFormatter, error) {
+	if name == "" {
+		name = defaultReviewFormat
+	}
+	f, ok := reviewFormatters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown review format %q (available: %s)", name, strings.Join(reviewFormats(), ", "))
+	}
+	return f, nil
+}
+
+// reviewFormatFlag is the review format set on the command line. It takes
+// precedence over the format configured globally or by a project.
+var reviewFormatFlag string
+
+// setReviewFormat overrides the configured review format, e.g. from a CLI flag.
+// An empty name keeps the configured format.
+func setReviewFormat(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := formatterFor(name); err != nil {
+		return err
+	}
+	reviewFormatFlag = name
+	cfg.Review.Format = name
+	return nil
+}
+
+// projectFormatter returns the configured formatter for p. The Markdown
+// format renders through the prompt template configured for the project.
+func projectFormatter(p *Project) (ReviewFormatter, error) {
+	f, err := formatterFor(p.Config().Review.Format)
+	if err != nil {
+		return nil, err
+	}
+	if f == reviewFormatters[defaultReviewFormat] {
+		tmpl, err := promptTemplate(p)
+		if err != nil {
+			return nil, err
+		}
+		return promptFormatter{tmpl}, nil
+	}
+	return f, nil
+}
+
+// Format renders the review using the named formatter.
+func (review *FormattedReview) Format(name string) (string, error) {
+	f, err := formatterFor(name)
+	if err != nil {
+		return "", err
+	}
+	return formatReview(f, review)
+}
+
+func formatReview(f ReviewFormatter, review *FormattedReview) (string, error) {
+	var s strings.Builder
+	if err := f.Format(&s, review); err != nil {
+		return "", err
+	}
+	return s.String(), nil
+}
+
+// jsonFormatter renders the review as indented JSON.
+type jsonFormatter struct{}
+
+func (jsonFormatter) Format(w io.Writer, review *FormattedReview) error {
+	slices.SortFunc(review.Comments, compareFormattedComment)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(review)
+}
+
+// sarifFormatter renders the review as a SARIF 2.1.0 log with one result per comment.
+type sarifFormatter struct{}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool                     sarifTool                 `json:"tool"`
+	VersionControlProvenance []sarifVersionControlInfo `json:"versionControlProvenance,omitempty"`
+	Results                  []sarifResult             `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifVersionControlInfo struct {
+	RepositoryURI string `json:"repositoryUri"`
+	RevisionID    string `json:"revisionId"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+var sarifRuleDescriptions = map[string]string{
+	"issue":      "Problem to fix",
+	"suggestion": "Suggested improvement",
+	"note":       "Observation",
+	"praise":     "Positive feedback",
+}
+
+func sarifLevel(commentType string) string {
+	switch commentType {
+	case "issue":
+		return "error"
+	case "suggestion":
+		return "warning"
+	case "praise":
+		return "none"
+	}
+	return "note"
+}
+
+func (sarifFormatter) Format(w io.Writer, review *FormattedReview) error {
+	slices.SortFunc(review.Comments, compareFormattedComment)
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "tcr",
+			InformationURI: "https://github.com/twistedogic/tcr",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	if review.Repository != "" && review.CommitSha != "" {
+		run.VersionControlProvenance = []sarifVersionControlInfo{{RepositoryURI: review.Repository, RevisionID: review.CommitSha}}
+	}
+	rules := map[string]bool{}
+	for _, c := range review.Comments {
+		ruleID := strings.ToLower(c.Type)
+		if !rules[ruleID] {
+			rules[ruleID] = true
+			desc := sarifRuleDescriptions[ruleID]
+			if desc == "" {
+				desc = strings.ToUpper(ruleID)
+			}
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: ruleID, ShortDescription: sarifMessage{Text: desc}})
+		}
+		result := sarifResult{
+			RuleID:  ruleID,
+			Level:   sarifLevel(ruleID),
+			Message: sarifMessage{Text: c.Content},
+		}
+		if c.File != "" {
+			loc := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: c.File}}
+			if c.Line > 0 {
+				loc.Region = &sarifRegion{StartLine: c.Line}
+			}
+			result.Locations = []sarifLocation{{PhysicalLocation: loc}}
+		}
+		if c.IsOldSide {
+			// Old-side lines refer to the base commit rather than the working tree.
+			result.Properties = map[string]string{"side": "old"}
+		}
+		run.Results = append(run.Results, result)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormattedReview_Format(t *testing.T) {
+	review, err := LoadReview("testdata/projector_388e9be_20260127_152442.json")
+	require.NoError(t, err)
+
+	markdown, err := review.Format("")
+	require.NoError(t, err)
+	require.Equal(t, review.String(), markdown)
+
+	out, err := review.Format("json")
+	require.NoError(t, err)
+	var decoded FormattedReview
+	require.NoError(t, json.Unmarshal([]byte(out), &decoded))
+	require.Equal(t, review.CommitSha, decoded.CommitSha)
+	require.Len(t, decoded.Comments, 3)
+	require.True(t, decoded.Comments[1].IsOldSide)
+
+	_, err = review.Format("xml")
+	require.Error(t, err)
+}
+
+func TestSarifFormatter(t *testing.T) {
+	review := &FormattedReview{
+		CommitSha:  "388e9be2840309204aef7b82c3f3e4d5985dfa7f",
+		Repository: "https://github.com/o/r",
+		Comments: []FormattedComment{
+			{File: "main.go", Line: 18, Type: "issue", Content: "fix", Index: 0},
+			{File: "main.go", Line: 0, Type: "praise", Content: "nice", Index: 1},
+			{File: "main.go", Line: 3, IsOldSide: true, Type: "note", Content: "old", Index: 2},
+		},
+	}
+	out, err := review.Format("sarif")
+	require.NoError(t, err)
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal([]byte(out), &log))
+	require.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	require.Len(t, run.Tool.Driver.Rules, 3)
+	require.Equal(t, review.CommitSha, run.VersionControlProvenance[0].RevisionID)
+	require.Len(t, run.Results, 3)
+
+	fileLevel := run.Results[0]
+	require.Equal(t, "praise", fileLevel.RuleID)
+	require.Equal(t, "none", fileLevel.Level)
+	require.Nil(t, fileLevel.Locations[0].PhysicalLocation.Region)
+
+	issue := run.Results[1]
+	require.Equal(t, "error", issue.Level)
+	require.Equal(t, 18, issue.Locations[0].PhysicalLocation.Region.StartLine)
+
+	old := run.Results[2]
+	require.Equal(t, "old", old.Properties["side"])
+}
+
+func TestSetReviewFormat(t *testing.T) {
+	orig := cfg
+	t.Cleanup(func() { cfg, reviewFormatFlag = orig, "" })
+
+	require.NoError(t, setReviewFormat(""))
+	require.Equal(t, orig.Review.Format, cfg.Review.Format)
+	require.NoError(t, setReviewFormat("sarif"))
+	require.Equal(t, "sarif", cfg.Review.Format)
+	require.Error(t, setReviewFormat("xml"))
+}
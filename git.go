@@ -1,6 +1,7 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"os"
@@ -56,6 +57,22 @@ func currentBranch(ctx context.Context, repoPath string) (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
+// headRef returns the branch checked out in repoPath, or HEAD if it is
+// detached.
+func headRef(ctx context.Context, repoPath string) (string, error) {
+	branch, err := currentBranch(ctx, repoPath)
+	return cmp.Or(branch, "HEAD"), err
+}
+
+// revParse resolves rev to a full commit SHA.
+func revParse(ctx context.Context, repoPath, rev string) (string, error) {
+	out, err := execute(ctx, repoPath, "git", "rev-parse", "--verify", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("rev-parse %s: %w", rev, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 func pull(ctx context.Context, path string) error {
 	_, err := execute(ctx, path, "git", "pull")
 	return err
@@ -78,3 +95,55 @@ func clone(ctx context.Context, workspace, owner, repo, branch string) error {
 	}
 	return nil
 }
+
+// listWorktrees returns the paths of the linked worktrees of the repo at
+// repoPath by the branch checked out in them. The main worktree and those
+// with a detached HEAD are left out.
+func listWorktrees(ctx context.Context, repoPath string) (map[string]string, error) {
+	out, err := execute(ctx, repoPath, "git", "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("list worktrees: %w", err)
+	}
+	worktrees := map[string]string{}
+	var (
+		path string
+		// n counts the worktrees; the main worktree is always listed first.
+		n int
+	)
+	for _, line := range strings.Split(string(out), "\n") {
+		if p, ok := strings.CutPrefix(line, "worktree "); ok {
+			path = p
+			n++
+		} else if ref, ok := strings.CutPrefix(line, "branch "); ok && n > 1 {
+			worktrees[strings.TrimPrefix(ref, "refs/heads/")] = path
+		}
+	}
+	return worktrees, nil
+}
+
+// addWorktree checks out branch into a new worktree at dir. Like
+// checkoutBranch it tracks the remote branch if there is one and creates a
+// new branch otherwise.
+func addWorktree(ctx context.Context, repoPath, dir, branch string) error {
+	_, _ = execute(ctx, repoPath, "git", "fetch", "--all")
+
+	args := []string{"worktree", "add", "-b", branch, dir}
+	if _, err := execute(ctx, repoPath, "git", "rev-parse", "--verify", "-q", "refs/heads/"+branch); err == nil {
+		args = []string{"worktree", "add", dir, branch}
+	} else if _, err := execute(ctx, repoPath, "git", "rev-parse", "--verify", "-q", "refs/remotes/origin/"+branch); err == nil {
+		args = append(args, "--track", "origin/"+branch)
+	}
+	if out, err := execute(ctx, repoPath, "git", args...); err != nil {
+		return fmt.Errorf("add worktree %q: %w: %s", branch, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// removeWorktree removes the worktree at dir from the repo at repoPath. It
+// fails if the worktree has uncommitted changes.
+func removeWorktree(ctx context.Context, repoPath, dir string) error {
+	if out, err := execute(ctx, repoPath, "git", "worktree", "remove", dir); err != nil {
+		return fmt.Errorf("remove worktree %s: %w: %s", dir, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type githubPublishedMsg struct {
+	project *Project
+	review  *GitHubReview
+	err     error
+}
+
+type GitHubConfig struct {
+	// APIURL is the base URL of the GitHub REST API.
+	APIURL string `yaml:"api_url"`
+	// TokenEnv names the environment variable holding the API token.
+	TokenEnv string `yaml:"token_env"`
+}
+
+var defaultGitHubConfig = GitHubConfig{
+	APIURL:   "https://api.github.com",
+	TokenEnv: "GITHUB_TOKEN",
+}
+
+// GitHubClient is a minimal client for the GitHub pull request review API.
+type GitHubClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func NewGitHubClient(baseURL, token string) *GitHubClient {
+	return &GitHubClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// newGitHubClient returns a client for the API URL and token configured for p.
+func newGitHubClient(p *Project) *GitHubClient {
+	gc := p.Config().GitHub
+	return NewGitHubClient(gc.APIURL, os.Getenv(gc.TokenEnv))
+}
+
+func (c *GitHubClient) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(data))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+type GitHubPullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"base"`
+}
+
+// PullRequestForBranch returns the open pull request whose head is branch.
+func (c *GitHubClient) PullRequestForBranch(ctx context.Context, owner, repo, branch string) (*GitHubPullRequest, error) {
+	query := url.Values{"head": {owner + ":" + branch}, "state": {"open"}}
+	var prs []GitHubPullRequest
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/%s/pulls?%s", owner, repo, query.Encode()), nil, &prs); err != nil {
+		return nil, err
+	}
+	if len(prs) == 0 {
+		return nil, fmt.Errorf("no open pull request for %s/%s branch %s", owner, repo, branch)
+	}
+	return &prs[0], nil
+}
+
+type GitHubReviewComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side"`
+	Body string `json:"body"`
+}
+
+type GitHubReviewRequest struct {
+	CommitID string                `json:"commit_id,omitempty"`
+	Body     string                `json:"body"`
+	Event    string                `json:"event"`
+	Comments []GitHubReviewComment `json:"comments"`
+}
+
+type GitHubReview struct {
+	ID      int64  `json:"id"`
+	HTMLURL string `json:"html_url"`
+	State   string `json:"state"`
+}
+
+// CreateReview submits a review on pull request number.
+func (c *GitHubClient) CreateReview(ctx context.Context, owner, repo string, number int, review GitHubReviewRequest) (*GitHubReview, error) {
+	var out GitHubReview
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/%s/pulls/%d/reviews", owner, repo, number), review, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func githubSide(c FormattedComment) string {
+	if c.IsOldSide {
+		return "LEFT"
+	}
+	return "RIGHT"
+}
+
+// newGitHubReviewRequest converts a review into a "create review" payload
+// anchored at the reviewed commit. Line comments become review comments on
+// the LEFT (old) or RIGHT (new) side.
+// The review API has no file-level comments, so those and the comments on no
+// file are listed in the summary body instead.
+func newGitHubReviewRequest(review *FormattedReview) GitHubReviewRequest {
+	req := GitHubReviewRequest{CommitID: review.CommitSha, Event: "COMMENT", Comments: []GitHubReviewComment{}}
+	var body strings.Builder
+	fmt.Fprintf(&body, "Review of %s", review.ShortCommit())
+	if review.Notes != "" {
+		body.WriteString("\n\n" + review.Notes)
+	}
+	var generalComments, fileComments []string
+	for _, c := range review.Comments {
+		text := c.CommentType() + " " + c.Content
+		if c.File == "" {
+			generalComments = append(generalComments, "- "+text)
+			continue
+		}
+		if c.Line == 0 {
+			fileComments = append(fileComments, fmt.Sprintf("- `%s`: %s", c.File, text))
+			continue
+		}
+		req.Comments = append(req.Comments, GitHubReviewComment{
+			Path: c.File,
+			Line: c.Line,
+			Side: githubSide(c),
+			Body: text,
+		})
+	}
+	if len(generalComments) > 0 {
+		body.WriteString("\n\n" + strings.Join(generalComments, "\n"))
+	}
+	if len(fileComments) > 0 {
+		body.WriteString("\n\nFile comments:\n" + strings.Join(fileComments, "\n"))
+	}
+	req.Body = body.String()
+	return req
+}
+
+// publishReview posts r as a review on the open pull request of its branch.
+func publishReview(ctx context.Context, client *GitHubClient, p *Project, r *ReviewRecord) (*GitHubReview, error) {
+	branch := r.Branch
+	if branch == "" {
+		branch = p.branch
+	}
+	pr, err := client.PullRequestForBranch(ctx, p.owner, p.repo, branch)
+	if err != nil {
+		return nil, err
+	}
+	return client.CreateReview(ctx, p.owner, p.repo, pr.Number, newGitHubReviewRequest(r.Review()))
+}
+
+type githubImportedMsg struct {
+	project *Project
+	review  *FormattedReview
+	err     error
+}
+
+type GitHubUser struct {
+	Login string `json:"login"`
+}
+
+type GitHubPullComment struct {
+	ID           int64      `json:"id"`
+	InReplyToID  int64      `json:"in_reply_to_id,omitempty"`
+	Path         string     `json:"path"`
+	Line         *int       `json:"line"`
+	OriginalLine *int       `json:"original_line"`
+	Side         string     `json:"side"`
+	Body         string     `json:"body"`
+	User         GitHubUser `json:"user"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// PullRequestComments returns all review comments of pull request number.
+func (c *GitHubClient) PullRequestComments(ctx context.Context, owner, repo string, number int) ([]GitHubPullComment, error) {
+	const perPage = 100
+	var all []GitHubPullComment
+	for page := 1; ; page++ {
+		var comments []GitHubPullComment
+		path := fmt.Sprintf("/repos/%s/%s/pulls/%d/comments?per_page=%d&page=%d", owner, repo, number, perPage, page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &comments); err != nil {
+			return nil, err
+		}
+		all = append(all, comments...)
+		if len(comments) < perPage {
+			return all, nil
+		}
+	}
+}
+
+func pullCommentType(body string) string {
+	if strings.Contains(body, "```suggestion") {
+		return "suggestion"
+	}
+	return "issue"
+}
+
+// reviewFromPullComments converts pull request review comments into a
+// FormattedReview. Replies are folded into the comment they answer, and
+// outdated comments that no longer map onto a line become file comments.
+func reviewFromPullComments(pr *GitHubPullRequest, comments []GitHubPullComment) *FormattedReview {
+	review := &FormattedReview{CommitSha: pr.Head.SHA, Source: "github"}
+	byID := map[int64]int{}
+	for _, pc := range comments {
+		text := fmt.Sprintf("%s\n(@%s)", strings.TrimSpace(pc.Body), pc.User.Login)
+		if idx, ok := byID[pc.InReplyToID]; ok && pc.InReplyToID != 0 {
+			review.Comments[idx].Content += "\n\nReply: " + text
+			byID[pc.ID] = idx
+			continue
+		}
+		c := FormattedComment{
+			File:      pc.Path,
+			Type:      pullCommentType(pc.Body),
+			Content:   text,
+			Index:     len(review.Comments),
+			CreatedAt: pc.CreatedAt,
+		}
+		switch {
+		case pc.Line != nil:
+			c.Line = *pc.Line
+			// LEFT lines number the base of the pull request.
+			if pc.Side == "LEFT" {
+				c.IsOldSide = true
+				c.OldCommit = pr.Base.SHA
+			}
+		case pc.OriginalLine != nil:
+			c.Content = fmt.Sprintf("(outdated, was line %d) %s", *pc.OriginalLine, c.Content)
+		}
+		byID[pc.ID] = len(review.Comments)
+		review.Comments = append(review.Comments, c)
+	}
+	return review
+}
+
+// importPullComments fetches the review comments of the open pull request
+// for the current branch of p.
+func importPullComments(ctx context.Context, client *GitHubClient, p *Project) (*FormattedReview, error) {
+	pr, err := client.PullRequestForBranch(ctx, p.owner, p.repo, p.branch)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := client.PullRequestComments(ctx, p.owner, p.repo, pr.Number)
+	if err != nil {
+		return nil, err
+	}
+	return reviewFromPullComments(pr, comments), nil
+}
+
+func importPullCommentsCmd(p *Project) tea.Cmd {
+	return func() tea.Msg {
+		review, err := importPullComments(context.Background(), newGitHubClient(p), p)
+		return githubImportedMsg{project: p, review: review, err: err}
+	}
+}
+
+func publishReviewCmd(p *Project, r *ReviewRecord) tea.Cmd {
+	return func() tea.Msg {
+		review, err := publishReview(context.Background(), newGitHubClient(p), p, r)
+		return githubPublishedMsg{project: p, review: review, err: err}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGitHubReviewRequest(t *testing.T) {
+	review := &FormattedReview{
+		CommitSha: "388e9be2840309204aef7b82c3f3e4d5985dfa7f",
+		Notes:     "Overall fine.",
+		Comments: []FormattedComment{
+			{File: "main.go", Type: "suggestion", Content: "Make it smaller"},
+			{File: "main.go", Line: 18, IsOldSide: true, Type: "note", Content: "ok"},
+			{File: "main.go", Line: 18, Type: "praise", Content: "Not ok"},
+			{Type: "issue", Content: "`make test` failed"},
+		},
+	}
+
+	req := newGitHubReviewRequest(review)
+	require.Equal(t, review.CommitSha, req.CommitID)
+	require.Equal(t, "COMMENT", req.Event)
+	require.Equal(t, "Review of 388e9be\n\nOverall fine.\n\n- **[ISSUE]** `make test` failed\n\nFile comments:\n- `main.go`: **[SUGGESTION]** Make it smaller", req.Body)
+	require.Equal(t, []GitHubReviewComment{
+		{Path: "main.go", Line: 18, Side: "LEFT", Body: "**[NOTE]** ok"},
+		{Path: "main.go", Line: 18, Side: "RIGHT", Body: "**[PRAISE]** Not ok"},
+	}, req.Comments)
+}
+
+func TestPublishReview(t *testing.T) {
+	// The handlers only record the requests; failing from their goroutines
+	// would not stop the test.
+	var (
+		head, auth string
+		got        GitHubReviewRequest
+		decodeErr  error
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		head, auth = r.URL.Query().Get("head"), r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`[{"number": 7, "html_url": "https://github.com/o/r/pull/7"}]`))
+	})
+	mux.HandleFunc("POST /repos/o/r/pulls/7/reviews", func(w http.ResponseWriter, r *http.Request) {
+		decodeErr = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"id": 1, "html_url": "https://github.com/o/r/pull/7#pullrequestreview-1", "state": "COMMENTED"}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	p := &Project{owner: "o", repo: "r", branch: "main"}
+	record := &ReviewRecord{
+		Branch:    "feature",
+		CommitSha: "388e9be",
+		Comments:  []FormattedComment{{File: "main.go", Line: 3, Type: "issue", Content: "bug"}},
+	}
+	review, err := publishReview(context.Background(), NewGitHubClient(srv.URL+"/", "secret"), p, record)
+	require.NoError(t, err)
+	require.Equal(t, "o:feature", head)
+	require.Equal(t, "Bearer secret", auth)
+	require.NoError(t, decodeErr)
+	require.Equal(t, "https://github.com/o/r/pull/7#pullrequestreview-1", review.HTMLURL)
+	require.Equal(t, "388e9be", got.CommitID)
+	require.Equal(t, []GitHubReviewComment{{Path: "main.go", Line: 3, Side: "RIGHT", Body: "**[ISSUE]** bug"}}, got.Comments)
+}
+
+func TestPublishReview_noPullRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	p := &Project{owner: "o", repo: "r", branch: "main"}
+	_, err := publishReview(context.Background(), NewGitHubClient(srv.URL, ""), p, &ReviewRecord{})
+	require.ErrorContains(t, err, "no open pull request")
+}
+
+func TestGitHubClient_errorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	_, err := NewGitHubClient(srv.URL, "bad").PullRequestForBranch(context.Background(), "o", "r", "main")
+	require.ErrorContains(t, err, "Bad credentials")
+}
+
+func TestImportPullComments(t *testing.T) {
+	var head, page string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		head = r.URL.Query().Get("head")
+		_, _ = w.Write([]byte(`[{"number": 7, "head": {"ref": "main", "sha": "abc123"}, "base": {"ref": "master", "sha": "def456"}}]`))
+	})
+	mux.HandleFunc("GET /repos/o/r/pulls/7/comments", func(w http.ResponseWriter, r *http.Request) {
+		page = r.URL.Query().Get("page")
+		_, _ = w.Write([]byte(`[
+			{"id": 1, "path": "main.go", "line": 12, "side": "RIGHT", "body": "Handle this error", "user": {"login": "alice"}},
+			{"id": 2, "in_reply_to_id": 1, "path": "main.go", "line": 12, "side": "RIGHT", "body": "+1", "user": {"login": "bob"}},
+			{"id": 3, "path": "util.go", "line": 4, "side": "LEFT", "body": "` + "```suggestion\\nx := 1\\n```" + `", "user": {"login": "bob"}},
+			{"id": 4, "path": "old.go", "line": null, "original_line": 9, "side": "RIGHT", "body": "Stale", "user": {"login": "alice"}}
+		]`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	p := &Project{owner: "o", repo: "r", branch: "main"}
+	review, err := importPullComments(context.Background(), NewGitHubClient(srv.URL, ""), p)
+	require.NoError(t, err)
+	require.Equal(t, "o:main", head)
+	require.Equal(t, "1", page)
+	require.Equal(t, "abc123", review.CommitSha)
+	require.Equal(t, "github", review.Source)
+	require.Len(t, review.Comments, 3)
+
+	require.Equal(t, FormattedComment{
+		File:    "main.go",
+		Line:    12,
+		Type:    "issue",
+		Content: "Handle this error\n(@alice)\n\nReply: +1\n(@bob)",
+		Index:   0,
+	}, review.Comments[0])
+	require.True(t, review.Comments[1].IsOldSide)
+	require.Equal(t, "def456", review.Comments[1].OldCommit)
+	require.Equal(t, "suggestion", review.Comments[1].Type)
+	require.Equal(t, 0, review.Comments[2].Line)
+	require.Equal(t, "(outdated, was line 9) Stale\n(@alice)", review.Comments[2].Content)
+}
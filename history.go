@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ReviewRecord is a review session stored in the project's review history.
+type ReviewRecord struct {
+	ID        string             `json:"id"`
+	Branch    string             `json:"branch,omitempty"`
+	Source    string             `json:"source,omitempty"`
+	CommitSha string             `json:"commit_sha"`
+	Comments  []FormattedComment `json:"comments"`
+	Files     []ReviewFile       `json:"files,omitempty"`
+	Notes     string             `json:"notes,omitempty"`
+	Prompt    string             `json:"prompt"`
+	Agent     string             `json:"agent,omitempty"`
+	Response  string             `json:"response,omitempty"`
+	Error     string             `json:"error,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+	SentAt    time.Time          `json:"sent_at,omitzero"`
+
+	Round       int                `json:"round,omitempty"`
+	PreviousSha string             `json:"previous_sha,omitempty"`
+	CarriedOver []FormattedComment `json:"carried_over,omitempty"`
+	Resolved    []FormattedComment `json:"resolved,omitempty"`
+
+	// Iteration counts the follow-ups with failures that led to the review.
+	Iteration int `json:"iteration,omitempty"`
+}
+
+func (r *ReviewRecord) Title() string {
+	title := fmt.Sprintf("%s · %s", r.CreatedAt.Local().Format("2006-01-02 15:04"), shortSha(r.CommitSha))
+	switch {
+	case r.Source != "":
+		title += fmt.Sprintf(" · %s from %s", r.Branch, r.Source)
+	case r.Branch != "":
+		title += fmt.Sprintf(" · %s round %d", r.Branch, max(r.Round, 1))
+	}
+	return title
+}
+
+func (r *ReviewRecord) Description() string {
+	status := "not sent"
+	switch {
+	case r.Error != "":
+		status = "agent failed"
+	case !r.SentAt.IsZero():
+		status = "sent to " + r.Agent
+	}
+	if r.Round > 1 {
+		return fmt.Sprintf("%d comments · %d carried over, %d resolved · %s", len(r.Comments), len(r.CarriedOver), len(r.Resolved), status)
+	}
+	return fmt.Sprintf("%d comments · %s", len(r.Comments), status)
+}
+
+func (r *ReviewRecord) FilterValue() string { return r.CommitSha + " " + r.Branch }
+
+// Review returns the stored comments as a FormattedReview.
+func (r *ReviewRecord) Review() *FormattedReview {
+	return &FormattedReview{
+		CommitSha: r.CommitSha,
+		Comments:  slices.Clone(r.Comments),
+		Files:     r.Files,
+		Notes:     r.Notes,
+		Source:    r.Source,
+	}
+}
+
+// Details renders the record, its prompt and the agent response for display.
+func (r *ReviewRecord) Details() string {
+	s := r.Prompt
+	if summary := r.RoundSummary(); summary != "" {
+		s = summary + "---\n" + s
+	}
+	if r.Error != "" {
+		s += "\n---\nError: " + r.Error + "\n"
+	}
+	if r.Response != "" {
+		s += "\n---\n" + r.Response
+	}
+	return s
+}
+
+func (p *Project) reviewStore() recordStore[ReviewRecord] {
+	return recordStore[ReviewRecord]{dir: p.stateDir("reviews")}
+}
+
+// Reviews returns the review history of the project, newest first.
+func (p *Project) Reviews() ([]*ReviewRecord, error) { return p.reviewStore().List() }
+
+// SaveReview stores r in the project's review history.
+func (p *Project) SaveReview(r *ReviewRecord) error { return p.reviewStore().Save(r.ID, r) }
+
+// newReviewRecord records a review of the current checkout of p as the round
+// following prev, the previous review of the same branch.
+func newReviewRecord(ctx context.Context, p *Project, review *FormattedReview, prev *ReviewRecord) (*ReviewRecord, error) {
+	if review.Repository == "" {
+		review.Repository = fmt.Sprintf("https://github.com/%s/%s", p.owner, p.repo)
+	}
+	prompt, err := buildPrompt(ctx, p, review)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	r := &ReviewRecord{
+		ID:        newRecordID(now),
+		Branch:    p.branch,
+		Source:    review.Source,
+		CommitSha: review.CommitSha,
+		Comments:  review.Comments,
+		Files:     review.Files,
+		Notes:     review.Notes,
+		Prompt:    prompt,
+		CreatedAt: now,
+	}
+	rev := r.CommitSha
+	if rev == "" {
+		rev = "HEAD"
+	}
+	if sha, err := revParse(ctx, p.path, rev); err == nil {
+		r.CommitSha = sha
+	}
+	if r.Source == "" {
+		r.startRound(prev)
+	}
+	return r, nil
+}
+
+type HistoryAction int
+
+const (
+	HistoryActionNone HistoryAction = iota
+	HistoryActionView
+	HistoryActionSend
+	HistoryActionPublish
+	HistoryActionBack
+)
+
+type historySelectedMsg struct {
+	action HistoryAction
+	record *ReviewRecord
+}
+
+type historyKeyMap struct {
+	View    key.Binding
+	Send    key.Binding
+	Publish key.Binding
+	Back    key.Binding
+}
+
+func (k historyKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.View, k.Send, k.Publish, k.Back}
+}
+
+func defaultHistoryKeyMap() historyKeyMap {
+	return historyKeyMap{
+		View:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "view")),
+		Send:    key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "send to agent")),
+		Publish: key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "publish to GitHub")),
+		Back:    key.NewBinding(key.WithKeys("esc", "q"), key.WithHelp("esc/q", "back")),
+	}
+}
+
+// ReviewHistory lists the stored reviews of a project.
+type ReviewHistory struct {
+	project *Project
+	list    list.Model
+	keyMap  historyKeyMap
+}
+
+func NewReviewHistory(p *Project, records []*ReviewRecord, width, height int) *ReviewHistory {
+	items := make([]list.Item, len(records))
+	for i, r := range records {
+		items[i] = r
+	}
+	keyMap := defaultHistoryKeyMap()
+	l := list.New(items, list.NewDefaultDelegate(), width, height)
+	l.Title = p.Title() + " – reviews"
+	l.SetStatusBarItemName("review", "reviews")
+	l.KeyMap.Quit.SetEnabled(false)
+	l.AdditionalFullHelpKeys = keyMap.ShortHelp
+	l.AdditionalShortHelpKeys = keyMap.ShortHelp
+	return &ReviewHistory{project: p, list: l, keyMap: keyMap}
+}
+
+func (h *ReviewHistory) Init() tea.Cmd { return nil }
+
+func (h *ReviewHistory) selected(action HistoryAction) tea.Cmd {
+	if r, ok := h.list.SelectedItem().(*ReviewRecord); ok {
+		return func() tea.Msg { return historySelectedMsg{action: action, record: r} }
+	}
+	return nil
+}
+
+func (h *ReviewHistory) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if h.list.SettingFilter() {
+			break
+		}
+		switch {
+		case key.Matches(msg, h.keyMap.View):
+			return h, h.selected(HistoryActionView)
+		case key.Matches(msg, h.keyMap.Send):
+			return h, h.selected(HistoryActionSend)
+		case key.Matches(msg, h.keyMap.Publish):
+			return h, h.selected(HistoryActionPublish)
+		case key.Matches(msg, h.keyMap.Back) && h.list.FilterState() == list.Unfiltered:
+			return h, func() tea.Msg { return historySelectedMsg{action: HistoryActionBack} }
+		}
+	case tea.WindowSizeMsg:
+		h.list.SetSize(msg.Width, msg.Height)
+	}
+	var cmd tea.Cmd
+	h.list, cmd = h.list.Update(msg)
+	return h, cmd
+}
+
+func (h *ReviewHistory) View() string { return h.list.View() }
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCanceled  JobStatus = "canceled"
+)
+
+// Done reports whether the job has finished.
+func (s JobStatus) Done() bool { return s != JobQueued && s != JobRunning }
+
+// Job is a background run of the non-interactive agent on a stored review.
+type Job struct {
+	ID       string    `json:"id"`
+	ReviewID string    `json:"review_id"`
+	Branch   string    `json:"branch,omitempty"`
+	Agent    string    `json:"agent"`
+	Status   JobStatus `json:"status"`
+	Progress string    `json:"progress,omitempty"`
+	// Warnings are problems that did not stop the job, e.g. parts of the
+	// review exceeding the prompt budget.
+	Warnings  []string  `json:"warnings,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	StartedAt time.Time `json:"started_at,omitzero"`
+	EndedAt   time.Time `json:"ended_at,omitzero"`
+	// ExitCode is the exit code of the agent, -1 if it did not exit by itself.
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+	Output   string `json:"output,omitempty"`
+	// TCR is the result of testing the agent's changes in tcr mode.
+	TCR *TCRResult `json:"tcr,omitempty"`
+	// FollowUp is the review of the failures found after the job, queued
+	// for the agent in a new job.
+	FollowUp string `json:"follow_up,omitempty"`
+}
+
+func (j *Job) Title() string {
+	title := fmt.Sprintf("%s · %s · review %s", j.CreatedAt.Local().Format("2006-01-02 15:04:05"), j.Status, j.ReviewID)
+	if j.Status == JobRunning && j.Progress != "" {
+		title += " · " + j.Progress
+	}
+	return title
+}
+
+func (j *Job) Description() string {
+	switch {
+	case j.Status == JobQueued:
+		return "waiting for " + j.Agent
+	case j.StartedAt.IsZero():
+		return string(j.Status) + " before it started"
+	case j.Status == JobRunning:
+		return fmt.Sprintf("%s running for %s", j.Agent, time.Since(j.StartedAt).Round(time.Second))
+	case j.Error != "":
+		return fmt.Sprintf("%s exited with %d after %s: %s", j.Agent, j.ExitCode, j.EndedAt.Sub(j.StartedAt).Round(time.Second), j.Error)
+	}
+	s := fmt.Sprintf("%s exited with %d after %s", j.Agent, j.ExitCode, j.EndedAt.Sub(j.StartedAt).Round(time.Second))
+	if j.TCR != nil {
+		s += " · " + j.TCR.Summary()
+	}
+	if j.FollowUp != "" {
+		s += " · failures sent back"
+	}
+	if len(j.Warnings) > 0 {
+		s += fmt.Sprintf(" · %d warnings", len(j.Warnings))
+	}
+	return s
+}
+
+func (j *Job) FilterValue() string { return j.ReviewID + " " + string(j.Status) }
+
+// Details renders the job and the agent output for display.
+func (j *Job) Details() string {
+	s := fmt.Sprintf("Job %s: %s\nReview: %s\nAgent: %s\n", j.ID, j.Status, j.ReviewID, j.Agent)
+	if !j.StartedAt.IsZero() {
+		s += "Started: " + j.StartedAt.Local().Format(time.DateTime) + "\n"
+	}
+	if !j.EndedAt.IsZero() {
+		s += fmt.Sprintf("Ended: %s (exit code %d)\n", j.EndedAt.Local().Format(time.DateTime), j.ExitCode)
+	}
+	if j.Error != "" {
+		s += "Error: " + j.Error + "\n"
+	}
+	for _, w := range j.Warnings {
+		s += "Warning: " + w + "\n"
+	}
+	if j.FollowUp != "" {
+		s += "Follow-up review: " + j.FollowUp + "\n"
+	}
+	if j.TCR != nil {
+		s += "TCR: " + j.TCR.Summary() + "\n"
+		if j.TCR.TestOutput != "" {
+			s += "\n--- tests\n" + j.TCR.TestOutput + "\n"
+		}
+	}
+	if j.Output != "" {
+		s += "\n---\n" + j.Output
+	}
+	return s
+}
+
+func (p *Project) jobStore() recordStore[Job] {
+	return recordStore[Job]{dir: p.stateDir("jobs")}
+}
+
+// JobEvent reports a change of a job of project.
+type JobEvent struct {
+	Project *Project
+	Job     Job
+}
+
+type queuedJob struct {
+	job     *Job
+	project *Project
+	record  *ReviewRecord
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// JobQueue runs agent jobs in the background, independent of the session
+// that queued them. Jobs of a project run one after the other, as agents
+// change the same working tree; jobs of different projects run concurrently.
+type JobQueue struct {
+	mu      sync.Mutex
+	active  map[string]*queuedJob
+	pending map[string][]*queuedJob
+	// working holds the project paths with a worker running their jobs.
+	working map[string]bool
+	subs    map[chan JobEvent]struct{}
+}
+
+func NewJobQueue() *JobQueue {
+	return &JobQueue{
+		active:  map[string]*queuedJob{},
+		pending: map[string][]*queuedJob{},
+		working: map[string]bool{},
+		subs:    map[chan JobEvent]struct{}{},
+	}
+}
+
+// Subscribe returns a channel receiving job events and a function ending the
+// subscription. Events are dropped while the channel is full.
+func (q *JobQueue) Subscribe() (<-chan JobEvent, func()) {
+	ch := make(chan JobEvent, 16)
+	q.mu.Lock()
+	q.subs[ch] = struct{}{}
+	q.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			q.mu.Lock()
+			delete(q.subs, ch)
+			q.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// update applies fn to the job of qj, stores it and notifies subscribers.
+func (q *JobQueue) update(qj *queuedJob, fn func(j *Job)) error {
+	q.mu.Lock()
+	fn(qj.job)
+	job := *qj.job
+	for ch := range q.subs {
+		select {
+		case ch <- JobEvent{Project: qj.project, Job: job}:
+		default:
+		}
+	}
+	q.mu.Unlock()
+	return qj.project.jobStore().Save(job.ID, &job)
+}
+
+// Enqueue queues a run of the non-interactive agent on record.
+func (q *JobQueue) Enqueue(p *Project, record *ReviewRecord) (*Job, error) {
+	now := time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	r := *record
+	qj := &queuedJob{
+		job: &Job{
+			ID:        newRecordID(now),
+			ReviewID:  record.ID,
+			Branch:    record.Branch,
+			Agent:     p.Config().NonInteractive.Agent,
+			Status:    JobQueued,
+			CreatedAt: now,
+			ExitCode:  -1,
+		},
+		project: p,
+		record:  &r,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	// Register the job before storing it so Jobs never sees it as abandoned.
+	q.mu.Lock()
+	q.active[qj.job.ID] = qj
+	q.mu.Unlock()
+	if err := q.update(qj, func(*Job) {}); err != nil {
+		q.mu.Lock()
+		delete(q.active, qj.job.ID)
+		q.mu.Unlock()
+		cancel()
+		return nil, err
+	}
+	q.mu.Lock()
+	q.pending[p.path] = append(q.pending[p.path], qj)
+	start := !q.working[p.path]
+	q.working[p.path] = true
+	job := *qj.job
+	q.mu.Unlock()
+	if start {
+		go q.work(p.path)
+	}
+	return &job, nil
+}
+
+// work runs the pending jobs of the project at path until none are left.
+func (q *JobQueue) work(path string) {
+	for {
+		q.mu.Lock()
+		pending := q.pending[path]
+		if len(pending) == 0 {
+			delete(q.pending, path)
+			delete(q.working, path)
+			q.mu.Unlock()
+			return
+		}
+		qj := pending[0]
+		q.pending[path] = pending[1:]
+		if qj.ctx.Err() == nil {
+			// Mark it running before releasing the lock so Cancel no longer
+			// treats it as queued.
+			qj.job.Status = JobRunning
+		}
+		q.mu.Unlock()
+		if qj.ctx.Err() == nil {
+			q.run(qj)
+		}
+	}
+}
+
+func (q *JobQueue) run(qj *queuedJob) {
+	defer qj.cancel()
+	p := qj.project
+	_ = q.update(qj, func(j *Job) { j.StartedAt = time.Now() })
+	output, result, err := withTCR(qj.ctx, p, qj.record, func() ([]byte, error) {
+		if err := checkpointBefore(qj.ctx, p, fmt.Sprintf("%s on review %s", qj.job.Agent, qj.record.ID)); err != nil {
+			return nil, err
+		}
+		return fixReview(qj.ctx, p, qj.record, func(part, parts int, warning string) {
+			_ = q.update(qj, func(j *Job) {
+				j.Progress = fmt.Sprintf("part %d/%d", part, parts)
+				if warning != "" {
+					j.Progress += ", over budget"
+					j.Warnings = append(j.Warnings, warning)
+				}
+			})
+		})
+	})
+	if saveErr := finishReview(p, qj.record, output, err); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	followUp, err := q.followUp(qj, result, err)
+	_ = q.update(qj, func(j *Job) {
+		j.EndedAt = time.Now()
+		j.Output = string(output)
+		j.Progress = ""
+		j.TCR = result
+		if followUp != nil {
+			j.FollowUp = followUp.ID
+		}
+		j.Status = JobSucceeded
+		j.ExitCode = 0
+		if err != nil {
+			j.Status = JobFailed
+			j.Error = err.Error()
+			j.ExitCode = -1
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				j.ExitCode = exitErr.ExitCode()
+			}
+		}
+		if qj.ctx.Err() != nil {
+			j.Status = JobCanceled
+		}
+	})
+	q.mu.Lock()
+	delete(q.active, qj.job.ID)
+	q.mu.Unlock()
+}
+
+// followUp queues a job with the failures found after the job of qj, whose
+// agent run ended with err, and returns its review, if any.
+func (q *JobQueue) followUp(qj *queuedJob, result *TCRResult, err error) (*ReviewRecord, error) {
+	testsFailed := result != nil && result.Tested && !result.Passed
+	if qj.ctx.Err() != nil || err != nil && !testsFailed {
+		return nil, err
+	}
+	review, fbErr := followUpReview(qj.ctx, qj.project, qj.record, result)
+	if fbErr != nil {
+		return nil, cmp.Or(err, fmt.Errorf("check for failures: %w", fbErr))
+	}
+	if review == nil {
+		return nil, err
+	}
+	// It runs after this job, as the worker of the project is still running.
+	if _, qErr := q.Enqueue(qj.project, review); qErr != nil {
+		return nil, cmp.Or(err, fmt.Errorf("queue follow-up: %w", qErr))
+	}
+	return review, err
+}
+
+// Cancel stops a queued or running job.
+func (q *JobQueue) Cancel(id string) error {
+	q.mu.Lock()
+	qj, ok := q.active[id]
+	if !ok {
+		q.mu.Unlock()
+		return fmt.Errorf("job %s is not queued or running", id)
+	}
+	// A running job is marked canceled by run once the agent has stopped.
+	qj.cancel()
+	queued := qj.job.Status == JobQueued
+	q.mu.Unlock()
+	if !queued {
+		return nil
+	}
+	err := q.update(qj, func(j *Job) {
+		j.Status = JobCanceled
+		j.EndedAt = time.Now()
+	})
+	q.mu.Lock()
+	delete(q.active, id)
+	q.mu.Unlock()
+	return err
+}
+
+// Retry queues the review of a finished job again.
+func (q *JobQueue) Retry(p *Project, job *Job) (*Job, error) {
+	if !job.Status.Done() {
+		return nil, fmt.Errorf("job %s is still %s", job.ID, job.Status)
+	}
+	record, err := p.reviewStore().Load(job.ReviewID)
+	if err != nil {
+		return nil, fmt.Errorf("load review %s: %w", job.ReviewID, err)
+	}
+	return q.Enqueue(p, record)
+}
+
+// Jobs returns the jobs of p, newest first. Jobs left queued or running by a
+// previous server process are marked as failed.
+func (q *JobQueue) Jobs(p *Project) ([]*Job, error) {
+	// List under the lock: a job leaves active only once its final state is
+	// stored, so a job missing from active is listed as it ended.
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs, err := p.jobStore().List()
+	if err != nil {
+		return nil, err
+	}
+	for i, j := range jobs {
+		if qj, ok := q.active[j.ID]; ok {
+			// The stored job may lag behind an update being saved.
+			job := *qj.job
+			jobs[i] = &job
+			continue
+		}
+		if !j.Status.Done() {
+			j.Status = JobFailed
+			j.Error = "interrupted by server restart"
+			if err := p.jobStore().Save(j.ID, j); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return jobs, nil
+}
+
+// Busy reports whether jobs of the project at path are running or queued.
+func (q *JobQueue) Busy(path string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.working[path]
+}
+
+// Counts returns the number of queued and running jobs across all projects.
+func (q *JobQueue) Counts() (queued, running int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, qj := range q.active {
+		if qj.job.Status == JobRunning {
+			running++
+		} else {
+			queued++
+		}
+	}
+	return queued, running
+}
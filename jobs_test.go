@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// agentScript configures script as the non-interactive agent, run on the
+// whole review at once.
+func agentScript(script string) func(c *AgentConfig) {
+	return func(c *AgentConfig) {
+		c.NonInteractive = AgentSection{Agent: "sh", Args: []string{"-c", script}}
+		c.Review.Parallel.Enabled = false
+		c.Review.Budget = BudgetConfig{}
+		// The project is no git repository.
+		c.Checkpoints.Enabled = false
+	}
+}
+
+// waitForJob returns the first event of job with a final status.
+func waitForJob(t *testing.T, events <-chan JobEvent, id string) Job {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Job.ID == id && ev.Job.Status.Done() {
+				return ev.Job
+			}
+		case <-timeout:
+			t.Fatalf("job %s did not finish", id)
+		}
+	}
+}
+
+func TestJobQueue_runsJobs(t *testing.T) {
+	p := testProject(t, agentScript(`case "$0" in *fail*) echo broken; exit 3;; esac; echo fixed`))
+	q := NewJobQueue()
+	events, unsubscribe := q.Subscribe()
+	defer unsubscribe()
+
+	ok := &ReviewRecord{ID: "r1", Prompt: "fix it"}
+	fail := &ReviewRecord{ID: "r2", Prompt: "fail"}
+	require.NoError(t, p.SaveReview(ok))
+	require.NoError(t, p.SaveReview(fail))
+	job1, err := q.Enqueue(p, ok)
+	require.NoError(t, err)
+	require.Equal(t, JobQueued, job1.Status)
+	job2, err := q.Enqueue(p, fail)
+	require.NoError(t, err)
+
+	done1 := waitForJob(t, events, job1.ID)
+	require.Equal(t, JobSucceeded, done1.Status)
+	require.Equal(t, 0, done1.ExitCode)
+	require.Equal(t, "fixed\n", done1.Output)
+	done2 := waitForJob(t, events, job2.ID)
+	require.Equal(t, JobFailed, done2.Status)
+	require.Equal(t, 3, done2.ExitCode)
+	require.False(t, done2.StartedAt.Before(done1.EndedAt), "jobs of a project run one after the other")
+
+	record, err := p.reviewStore().Load("r1")
+	require.NoError(t, err)
+	require.Equal(t, "fixed\n", record.Response)
+	require.Equal(t, "sh", record.Agent)
+
+	jobs, err := q.Jobs(p)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	require.Equal(t, job2.ID, jobs[0].ID)
+	require.Equal(t, JobFailed, jobs[0].Status)
+
+	retried, err := q.Retry(p, jobs[0])
+	require.NoError(t, err)
+	require.Equal(t, "r2", retried.ReviewID)
+	require.Equal(t, JobFailed, waitForJob(t, events, retried.ID).Status)
+}
+
+func TestJobQueue_Cancel(t *testing.T) {
+	p := testProject(t, agentScript(`sleep 30`))
+	q := NewJobQueue()
+	events, unsubscribe := q.Subscribe()
+	defer unsubscribe()
+
+	running, err := q.Enqueue(p, &ReviewRecord{ID: "r1", Prompt: "a"})
+	require.NoError(t, err)
+	queued, err := q.Enqueue(p, &ReviewRecord{ID: "r2", Prompt: "b"})
+	require.NoError(t, err)
+
+	require.NoError(t, q.Cancel(queued.ID))
+	require.Equal(t, JobCanceled, waitForJob(t, events, queued.ID).Status)
+	require.Error(t, q.Cancel(queued.ID))
+
+	require.Eventually(t, func() bool {
+		_, running := q.Counts()
+		return running == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.True(t, q.Busy(p.path))
+	require.False(t, q.Busy(t.TempDir()))
+	require.NoError(t, q.Cancel(running.ID))
+	require.Equal(t, JobCanceled, waitForJob(t, events, running.ID).Status)
+	require.Eventually(t, func() bool { return !q.Busy(p.path) }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestJobQueue_Jobs_interrupted(t *testing.T) {
+	p := testProject(t, agentScript(`true`))
+	require.NoError(t, p.jobStore().Save("1", &Job{ID: "1", Status: JobRunning}))
+
+	jobs, err := NewJobQueue().Jobs(p)
+	require.NoError(t, err)
+	require.Equal(t, JobFailed, jobs[0].Status)
+	require.Equal(t, "interrupted by server restart", jobs[0].Error)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type JobsAction int
+
+const (
+	JobsActionNone JobsAction = iota
+	JobsActionView
+	JobsActionCancel
+	JobsActionRetry
+	JobsActionBack
+)
+
+type jobsSelectedMsg struct {
+	action JobsAction
+	job    *Job
+}
+
+type jobsKeyMap struct {
+	View   key.Binding
+	Cancel key.Binding
+	Retry  key.Binding
+	Back   key.Binding
+}
+
+func (k jobsKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.View, k.Cancel, k.Retry, k.Back}
+}
+
+func defaultJobsKeyMap() jobsKeyMap {
+	return jobsKeyMap{
+		View:   key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "output")),
+		Cancel: key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "cancel")),
+		Retry:  key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "retry")),
+		Back:   key.NewBinding(key.WithKeys("esc", "q"), key.WithHelp("esc/q", "back")),
+	}
+}
+
+// JobList lists the background agent jobs of a project.
+type JobList struct {
+	project *Project
+	list    list.Model
+	keyMap  jobsKeyMap
+}
+
+func NewJobList(p *Project, jobs []*Job, width, height int) *JobList {
+	items := make([]list.Item, len(jobs))
+	for i, j := range jobs {
+		items[i] = j
+	}
+	keyMap := defaultJobsKeyMap()
+	l := list.New(items, list.NewDefaultDelegate(), width, height)
+	l.Title = p.Title() + " – jobs"
+	l.SetStatusBarItemName("job", "jobs")
+	l.KeyMap.Quit.SetEnabled(false)
+	l.AdditionalFullHelpKeys = keyMap.ShortHelp
+	l.AdditionalShortHelpKeys = keyMap.ShortHelp
+	return &JobList{project: p, list: l, keyMap: keyMap}
+}
+
+// SetJob shows the latest state of job, adding it on top if it is new.
+func (l *JobList) SetJob(job Job) tea.Cmd {
+	for i, item := range l.list.Items() {
+		if j, ok := item.(*Job); ok && j.ID == job.ID {
+			return l.list.SetItem(i, &job)
+		}
+	}
+	return l.list.InsertItem(0, &job)
+}
+
+func (l *JobList) Init() tea.Cmd { return nil }
+
+func (l *JobList) selected(action JobsAction) tea.Cmd {
+	if j, ok := l.list.SelectedItem().(*Job); ok {
+		return func() tea.Msg { return jobsSelectedMsg{action: action, job: j} }
+	}
+	return nil
+}
+
+func (l *JobList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if l.list.SettingFilter() {
+			break
+		}
+		switch {
+		case key.Matches(msg, l.keyMap.View):
+			return l, l.selected(JobsActionView)
+		case key.Matches(msg, l.keyMap.Cancel):
+			return l, l.selected(JobsActionCancel)
+		case key.Matches(msg, l.keyMap.Retry):
+			return l, l.selected(JobsActionRetry)
+		case key.Matches(msg, l.keyMap.Back) && l.list.FilterState() == list.Unfiltered:
+			return l, func() tea.Msg { return jobsSelectedMsg{action: JobsActionBack} }
+		}
+	case tea.WindowSizeMsg:
+		l.list.SetSize(msg.Width, msg.Height)
+	}
+	var cmd tea.Cmd
+	l.list, cmd = l.list.Update(msg)
+	return l, cmd
+}
+
+func (l *JobList) View() string { return l.list.View() }
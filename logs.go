@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// LogsConfig controls the agent logs kept per project.
+type LogsConfig struct {
+	// Transcripts records a terminal transcript of interactive agent
+	// sessions with script(1).
+	Transcripts bool `yaml:"transcripts"`
+	// MaxAgeDays removes logs older than this many days. Zero keeps them.
+	MaxAgeDays int `yaml:"max_age_days"`
+	// MaxFiles keeps at most this many logs per project. Zero keeps all.
+	MaxFiles int `yaml:"max_files"`
+}
+
+var defaultLogsConfig = LogsConfig{
+	Transcripts: true,
+	MaxAgeDays:  30,
+	MaxFiles:    200,
+}
+
+const (
+	logKindAgent       = "agent"
+	logKindInteractive = "interactive"
+)
+
+// LogFile is an agent log of a project: the output of a non-interactive run
+// or the transcript of an interactive session.
+type LogFile struct {
+	Path    string
+	Kind    string
+	Time    time.Time
+	Size    int64
+	Command string
+}
+
+func (l *LogFile) Title() string {
+	return fmt.Sprintf("%s · %s", l.Time.Local().Format("2006-01-02 15:04:05"), l.Kind)
+}
+
+func (l *LogFile) Description() string {
+	return fmt.Sprintf("%s · %d bytes", l.Command, l.Size)
+}
+
+func (l *LogFile) FilterValue() string { return l.Kind + " " + l.Command }
+
+// Content returns the log with terminal control sequences removed.
+func (l *LogFile) Content() (string, error) {
+	data, err := os.ReadFile(l.Path)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.ReplaceAll(ansi.Strip(string(data)), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		// A carriage return redraws the line, as progress indicators do.
+		if j := strings.LastIndexByte(line, '\r'); j >= 0 {
+			lines[i] = line[j+1:]
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (p *Project) logDir() string { return p.stateDir("logs") }
+
+// newLogPath returns the path of a new log of kind for p, creating the log
+// directory and removing logs beyond the configured retention first.
+func (p *Project) newLogPath(kind, cmd string) (string, error) {
+	dir := p.logDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("create %s: %w", dir, err)
+	}
+	if err := pruneLogs(dir, p.Config().Logs, time.Now()); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s-%s-%s.log", newRecordID(time.Now()), kind, logCommandName(cmd))
+	return filepath.Join(dir, name), nil
+}
+
+// logCommandName makes the base name of cmd safe for use in a file name.
+func logCommandName(cmd string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, filepath.Base(cmd))
+}
+
+// parseLogName splits a log file name into its time, kind and command.
+func parseLogName(name string) (t time.Time, kind, cmd string, ok bool) {
+	name, ok = strings.CutSuffix(name, ".log")
+	if !ok {
+		return
+	}
+	parts := strings.SplitN(name, "-", 4)
+	if len(parts) != 4 {
+		return t, "", "", false
+	}
+	t, err := time.Parse("20060102-150405.000000000", parts[0]+"-"+parts[1])
+	if err != nil {
+		return t, "", "", false
+	}
+	return t, parts[2], parts[3], true
+}
+
+// Logs returns the agent logs of p, newest first.
+func (p *Project) Logs() ([]*LogFile, error) {
+	return listLogs(p.logDir())
+}
+
+func listLogs(dir string) ([]*LogFile, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var logs []*LogFile
+	for _, e := range entries {
+		t, kind, cmd, ok := parseLogName(e.Name())
+		if !ok || e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		logs = append(logs, &LogFile{Path: filepath.Join(dir, e.Name()), Kind: kind, Time: t, Size: info.Size(), Command: cmd})
+	}
+	slices.SortFunc(logs, func(a, b *LogFile) int { return b.Time.Compare(a.Time) })
+	return logs, nil
+}
+
+// pruneLogs removes the logs in dir that are older than the retention period
+// and those beyond the maximum number of files, oldest first.
+func pruneLogs(dir string, lc LogsConfig, now time.Time) error {
+	logs, err := listLogs(dir)
+	if err != nil {
+		return err
+	}
+	for i, l := range logs {
+		expired := lc.MaxAgeDays > 0 && now.Sub(l.Time) > time.Duration(lc.MaxAgeDays)*24*time.Hour
+		// Leave room for the log about to be written.
+		excess := lc.MaxFiles > 0 && i >= lc.MaxFiles-1
+		if expired || excess {
+			if err := os.Remove(l.Path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeAgentLog records a non-interactive agent run in dir in the logs of p.
+func writeAgentLog(p *Project, dir string, agent AgentSection, prompt string, output []byte, runErr error, started time.Time) error {
+	path, err := p.newLogPath(logKindAgent, agent.Agent)
+	if err != nil {
+		return err
+	}
+	status := "ok"
+	if runErr != nil {
+		status = runErr.Error()
+	}
+	var s strings.Builder
+	fmt.Fprintf(&s, "$ %s\n", strings.Join(append([]string{agent.Agent}, agent.Args...), " "))
+	fmt.Fprintf(&s, "dir: %s\nstarted: %s\nended: %s\nstatus: %s\n", dir, started.Format(time.RFC3339), time.Now().Format(time.RFC3339), status)
+	fmt.Fprintf(&s, "\n--- prompt\n%s\n\n--- output\n%s", strings.TrimRight(prompt, "\n"), output)
+	return os.WriteFile(path, []byte(s.String()), 0644)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// transcriptCommand wraps cmd in script(1) so that the interactive session
+// is recorded in the logs of p. Without transcripts or script(1) the
+// command is returned unchanged.
+func transcriptCommand(p *Project, cmd string, args []string) (string, []string) {
+	if !p.Config().Logs.Transcripts {
+		return cmd, args
+	}
+	script, err := exec.LookPath("script")
+	if err != nil {
+		return cmd, args
+	}
+	path, err := p.newLogPath(logKindInteractive, cmd)
+	if err != nil {
+		return cmd, args
+	}
+	if runtime.GOOS == "linux" {
+		quoted := make([]string, 0, len(args)+1)
+		for _, a := range append([]string{cmd}, args...) {
+			quoted = append(quoted, shellQuote(a))
+		}
+		return script, []string{"-q", "-f", "-e", "-c", strings.Join(quoted, " "), path}
+	}
+	// BSD script takes the command after the transcript file.
+	return script, append([]string{"-q", path, cmd}, args...)
+}
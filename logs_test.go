@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunAgent_writesLog(t *testing.T) {
+	p := testProject(t)
+	agent := AgentSection{Agent: "sh", Args: []string{"-c", `echo "fixing $0"; echo oops >&2; exit 2`}}
+
+	_, err := runAgent(context.Background(), p, p.path, agent, "the prompt")
+	require.Error(t, err)
+
+	logs, err := p.Logs()
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, logKindAgent, logs[0].Kind)
+	require.Equal(t, "sh", logs[0].Command)
+	content, err := logs[0].Content()
+	require.NoError(t, err)
+	require.Contains(t, content, "status: exit status 2")
+	require.Contains(t, content, "--- prompt\nthe prompt\n")
+	require.Contains(t, content, "fixing the prompt\n")
+	require.Contains(t, content, "oops\n")
+}
+
+func TestPruneLogs(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	for _, age := range []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour, 40 * 24 * time.Hour} {
+		name := newRecordID(now.Add(-age)) + "-agent-sh.log"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644))
+
+	require.NoError(t, pruneLogs(dir, LogsConfig{MaxAgeDays: 30}, now))
+	logs, err := listLogs(dir)
+	require.NoError(t, err)
+	require.Len(t, logs, 3)
+	require.Equal(t, now.Add(-time.Hour), logs[0].Time)
+
+	// Room is left for the log about to be written.
+	require.NoError(t, pruneLogs(dir, LogsConfig{MaxFiles: 2}, now))
+	logs, err = listLogs(dir)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, now.Add(-time.Hour), logs[0].Time)
+	require.FileExists(t, filepath.Join(dir, "notes.txt"))
+}
+
+func TestLogFile_Content(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "t.log")
+	require.NoError(t, os.WriteFile(path, []byte("\x1b[1;32mok\x1b[0m\r\nprogress\rdone\r\n"), 0644))
+	content, err := (&LogFile{Path: path}).Content()
+	require.NoError(t, err)
+	require.Equal(t, "ok\ndone\n", content)
+}
+
+func TestTranscriptCommand(t *testing.T) {
+	p := testProject(t)
+	p.config.Logs.Transcripts = false
+	cmd, args := transcriptCommand(p, "crush", []string{"--yolo"})
+	require.Equal(t, "crush", cmd)
+	require.Equal(t, []string{"--yolo"}, args)
+
+	p.config.Logs.Transcripts = true
+	cmd, args = transcriptCommand(p, "crush", []string{"it's"})
+	if !strings.HasSuffix(cmd, "script") {
+		t.Skip("script(1) is not available")
+	}
+	require.Contains(t, args, "-q")
+	require.Contains(t, strings.Join(args, " "), `'it'\''s'`)
+	i := slices.IndexFunc(args, func(a string) bool { return strings.HasSuffix(a, ".log") })
+	require.GreaterOrEqual(t, i, 0)
+	path := args[i]
+	require.Equal(t, p.logDir(), filepath.Dir(path))
+	_, kind, command, ok := parseLogName(filepath.Base(path))
+	require.True(t, ok)
+	require.Equal(t, logKindInteractive, kind)
+	require.Equal(t, "crush", command)
+}
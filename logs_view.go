@@ -0,0 +1,84 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type LogsAction int
+
+const (
+	LogsActionNone LogsAction = iota
+	LogsActionView
+	LogsActionBack
+)
+
+type logsSelectedMsg struct {
+	action LogsAction
+	log    *LogFile
+}
+
+type logsKeyMap struct {
+	View key.Binding
+	Back key.Binding
+}
+
+func (k logsKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.View, k.Back}
+}
+
+func defaultLogsKeyMap() logsKeyMap {
+	return logsKeyMap{
+		View: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "view")),
+		Back: key.NewBinding(key.WithKeys("esc", "q"), key.WithHelp("esc/q", "back")),
+	}
+}
+
+// LogList lists the agent logs of a project.
+type LogList struct {
+	project *Project
+	list    list.Model
+	keyMap  logsKeyMap
+}
+
+func NewLogList(p *Project, logs []*LogFile, width, height int) *LogList {
+	items := make([]list.Item, len(logs))
+	for i, l := range logs {
+		items[i] = l
+	}
+	keyMap := defaultLogsKeyMap()
+	l := list.New(items, list.NewDefaultDelegate(), width, height)
+	l.Title = p.Title() + " – agent logs"
+	l.SetStatusBarItemName("log", "logs")
+	l.KeyMap.Quit.SetEnabled(false)
+	l.AdditionalFullHelpKeys = keyMap.ShortHelp
+	l.AdditionalShortHelpKeys = keyMap.ShortHelp
+	return &LogList{project: p, list: l, keyMap: keyMap}
+}
+
+func (l *LogList) Init() tea.Cmd { return nil }
+
+func (l *LogList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if l.list.SettingFilter() {
+			break
+		}
+		switch {
+		case key.Matches(msg, l.keyMap.View):
+			if f, ok := l.list.SelectedItem().(*LogFile); ok {
+				return l, func() tea.Msg { return logsSelectedMsg{action: LogsActionView, log: f} }
+			}
+		case key.Matches(msg, l.keyMap.Back) && l.list.FilterState() == list.Unfiltered:
+			return l, func() tea.Msg { return logsSelectedMsg{action: LogsActionBack} }
+		}
+	case tea.WindowSizeMsg:
+		l.list.SetSize(msg.Width, msg.Height)
+	}
+	var cmd tea.Cmd
+	l.list, cmd = l.list.Update(msg)
+	return l, cmd
+}
+
+func (l *LogList) View() string { return l.list.View() }
@@ -17,6 +17,7 @@ func main() {
 
 	subcommands.Register(&Server{}, "")
 	subcommands.Register(&appCmd{}, "")
+	subcommands.Register(&reviewCmd{}, "")
 
 	flag.Parse()
 	ctx := context.Background()
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type outputClosedMsg struct{}
+
+var (
+	outputTitleStyle = lipgloss.NewStyle().Bold(true).Padding(0, 1).
+				Foreground(lipgloss.Color("230")).Background(lipgloss.Color("62"))
+	outputHelpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// OutputView shows a read-only, scrollable block of text such as agent output.
+type OutputView struct {
+	title    string
+	viewport viewport.Model
+	close    key.Binding
+}
+
+func NewOutputView(title, content string, width, height int) *OutputView {
+	vp := viewport.New(width, max(height-3, 1))
+	vp.SetContent(content)
+	return &OutputView{
+		title:    title,
+		viewport: vp,
+		close:    key.NewBinding(key.WithKeys("esc", "q"), key.WithHelp("esc/q", "back")),
+	}
+}
+
+func (o *OutputView) Init() tea.Cmd { return nil }
+
+func (o *OutputView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if key.Matches(msg, o.close) {
+			return o, func() tea.Msg { return outputClosedMsg{} }
+		}
+	case tea.WindowSizeMsg:
+		o.viewport.Width = msg.Width
+		o.viewport.Height = max(msg.Height-3, 1)
+	}
+	var cmd tea.Cmd
+	o.viewport, cmd = o.viewport.Update(msg)
+	return o, cmd
+}
+
+func (o *OutputView) View() string {
+	return outputTitleStyle.Render(o.title) + "\n\n" +
+		o.viewport.View() + "\n" +
+		outputHelpStyle.Render("↑/↓ scroll • esc/q back")
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+type ParallelConfig struct {
+	// Enabled splits reviews into groups that are fixed by concurrent agent
+	// runs, each in a temporary worktree of its own.
+	Enabled bool `yaml:"enabled"`
+	// GroupBy is "file" or "dir" (the package directory of the file).
+	GroupBy string `yaml:"group_by"`
+	// MaxConcurrency bounds the number of agents running at once.
+	MaxConcurrency int `yaml:"max_concurrency"`
+}
+
+var defaultParallelConfig = ParallelConfig{
+	GroupBy:        "file",
+	MaxConcurrency: maxConcurrency,
+}
+
+func groupKey(file, by string) string {
+	if by == "dir" {
+		return filepath.Dir(file)
+	}
+	return file
+}
+
+// splitReview splits review into one review per file or directory, in the
+// order the groups first appear in the sorted comments. Every group is
+// scoped to the files of its comments, so the groups never share a file. A
+// review with a comment on no file is not split, as it may concern any file.
+func splitReview(review *FormattedReview, by string) []*FormattedReview {
+	if slices.ContainsFunc(review.Comments, func(c FormattedComment) bool { return c.File == "" }) {
+		return []*FormattedReview{review}
+	}
+	comments := slices.Clone(review.Comments)
+	slices.SortFunc(comments, compareFormattedComment)
+	var order []string
+	groups := map[string]*FormattedReview{}
+	for _, c := range comments {
+		k := groupKey(c.File, by)
+		g, ok := groups[k]
+		if !ok {
+			g = &FormattedReview{
+				CommitSha:  review.CommitSha,
+				Repository: review.Repository,
+				Notes:      review.Notes,
+				Source:     review.Source,
+				Group:      k,
+			}
+			groups[k] = g
+			order = append(order, k)
+		}
+		g.Comments = append(g.Comments, c)
+		if !slices.Contains(g.Scope, c.File) {
+			g.Scope = append(g.Scope, c.File)
+		}
+	}
+	out := make([]*FormattedReview, 0, len(order))
+	for _, k := range order {
+		g := groups[k]
+		for _, f := range review.Files {
+			if slices.Contains(g.Scope, f.Path) {
+				g.Files = append(g.Files, f)
+			}
+		}
+		out = append(out, g)
+	}
+	return out
+}
+
+type groupResult struct {
+	Group  string
+	Output []byte
+	Err    error
+}
+
+// runParallel runs the agent concurrently on every group of review, at most
+// limit at a time. Each group runs in a worktree of its own that starts from
+// the current working tree of p; see runGroup. A failing group does not stop
+// the others. Groups exceeding the prompt budget are sent in parts.
+func runParallel(ctx context.Context, p *Project, agent AgentSection, groups []*FormattedReview, limit int) []groupResult {
+	results := make([]groupResult, len(groups))
+	for i, group := range groups {
+		results[i].Group = group.Group
+	}
+	base, _, err := snapshotCommit(ctx, p.path, "tcr parallel review")
+	if err != nil {
+		for i := range results {
+			results[i].Err = fmt.Errorf("snapshot %s: %w", p.Title(), err)
+		}
+		return results
+	}
+	var (
+		g     errgroup.Group
+		apply sync.Mutex
+	)
+	g.SetLimit(max(limit, 1))
+	for i, group := range groups {
+		g.Go(func() error {
+			results[i].Output, results[i].Err = runGroup(ctx, p, agent, base, group, &apply)
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return results
+}
+
+// worktreeMu serializes adding and removing the group worktrees, as
+// concurrent git worktree commands read each other's half-written state.
+var worktreeMu sync.Mutex
+
+// groupWorktree runs git worktree with args in the repository at dir.
+func groupWorktree(ctx context.Context, dir string, args ...string) (string, error) {
+	worktreeMu.Lock()
+	defer worktreeMu.Unlock()
+	return git(ctx, dir, nil, append([]string{"worktree"}, args...)...)
+}
+
+// runGroup runs the agent on group in a temporary worktree checked out at
+// base, the snapshot of the working tree of p. The changes are applied to
+// p, holding apply, only if they stay within the scope of the group, so that
+// concurrent groups never edit the same file.
+func runGroup(ctx context.Context, p *Project, agent AgentSection, base string, group *FormattedReview, apply *sync.Mutex) ([]byte, error) {
+	tmp, err := os.MkdirTemp("", "tcr-group-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	dir := filepath.Join(tmp, "worktree")
+	if _, err := groupWorktree(ctx, p.path, "add", "-q", "--detach", dir, base); err != nil {
+		return nil, err
+	}
+	defer groupWorktree(context.WithoutCancel(ctx), p.path, "remove", "--force", dir)
+
+	output, err := runReview(ctx, p, dir, agent, group, nil)
+	if err != nil {
+		return output, err
+	}
+	if _, err := git(ctx, dir, nil, "add", "-A"); err != nil {
+		return output, err
+	}
+	changed, err := git(ctx, dir, nil, "diff", "--cached", "--no-renames", "--name-only", base)
+	if err != nil || changed == "" {
+		return output, err
+	}
+	var outside []string
+	for _, file := range strings.Split(changed, "\n") {
+		if !slices.Contains(group.Scope, file) {
+			outside = append(outside, file)
+		}
+	}
+	if len(outside) > 0 {
+		return output, fmt.Errorf("changes discarded, files outside the group were changed: %s", strings.Join(outside, ", "))
+	}
+	patch := filepath.Join(tmp, "changes.patch")
+	if _, err := git(ctx, dir, nil, "diff", "--cached", "--binary", "--output="+patch, base); err != nil {
+		return output, err
+	}
+	apply.Lock()
+	defer apply.Unlock()
+	_, err = git(ctx, p.path, nil, "apply", "--binary", patch)
+	return output, err
+}
+
+// parallelReport summarises the group results, returning an error if any group failed.
+func parallelReport(results []groupResult) ([]byte, error) {
+	var s strings.Builder
+	var failed []string
+	for _, r := range results {
+		status := "ok"
+		if r.Err != nil {
+			status = "failed: " + r.Err.Error()
+			failed = append(failed, r.Group)
+		}
+		fmt.Fprintf(&s, "== %s: %s\n%s\n", r.Group, status, strings.TrimRight(string(r.Output), "\n"))
+	}
+	if len(failed) > 0 {
+		return []byte(s.String()), fmt.Errorf("%d of %d groups failed: %s", len(failed), len(results), strings.Join(failed, ", "))
+	}
+	return []byte(s.String()), nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitReview(t *testing.T) {
+	review := &FormattedReview{
+		CommitSha: "abc",
+		Notes:     "notes",
+		Files:     []ReviewFile{{Path: "pkg/a.go"}, {Path: "pkg/b.go", Reviewed: true}, {Path: "main.go"}},
+		Comments: []FormattedComment{
+			{File: "main.go", Line: 1, Type: "issue", Content: "m", Index: 0},
+			{File: "pkg/a.go", Line: 2, Type: "issue", Content: "a", Index: 1},
+			{File: "pkg/b.go", Line: 3, Type: "issue", Content: "b", Index: 2},
+			{File: "pkg/a.go", Line: 9, Type: "note", Content: "a2", Index: 3},
+		},
+	}
+
+	byFile := splitReview(review, "file")
+	require.Len(t, byFile, 3)
+	require.Equal(t, "pkg/a.go", byFile[0].Group)
+	require.Len(t, byFile[0].Comments, 2)
+	require.Equal(t, []string{"pkg/a.go"}, byFile[0].Scope)
+	require.Equal(t, []ReviewFile{{Path: "pkg/a.go"}}, byFile[0].Files)
+	require.Equal(t, "notes", byFile[0].Notes)
+
+	byDir := splitReview(review, "dir")
+	require.Len(t, byDir, 2)
+	require.Equal(t, "pkg", byDir[0].Group)
+	require.ElementsMatch(t, []string{"pkg/a.go", "pkg/b.go"}, byDir[0].Scope)
+	require.Equal(t, ".", byDir[1].Group)
+	require.Contains(t, byDir[0].String(), "Only change the following files:\n- `pkg/a.go`\n- `pkg/b.go`\n\n")
+
+	review.Comments = append(review.Comments, FormattedComment{Type: "issue", Content: "`make lint` failed", Index: 4})
+	require.Equal(t, []*FormattedReview{review}, splitReview(review, "file"), "a general comment keeps the review whole")
+}
+
+func TestRunParallel(t *testing.T) {
+	_, local := setupBareRepo(t)
+	p := &Project{owner: "o", repo: "r", path: local}
+	agent := AgentSection{Agent: "sh", Args: []string{"-c", `case "$0" in *fail.go*) echo broken; exit 1;; esac; echo fixed`}}
+	groups := splitReview(&FormattedReview{Comments: []FormattedComment{
+		{File: "ok.go", Line: 1, Type: "issue", Content: "x"},
+		{File: "fail.go", Line: 1, Type: "issue", Content: "y"},
+		{File: "other.go", Line: 1, Type: "issue", Content: "z"},
+	}}, "file")
+
+	results := runParallel(context.Background(), p, agent, groups, 2)
+	require.Len(t, results, 3)
+	for _, r := range results {
+		if r.Group == "fail.go" {
+			require.Error(t, r.Err)
+			continue
+		}
+		require.NoError(t, r.Err)
+		require.Equal(t, "fixed\n", string(r.Output))
+	}
+
+	report, err := parallelReport(results)
+	require.ErrorContains(t, err, "1 of 3 groups failed: fail.go")
+	require.Contains(t, string(report), "== ok.go: ok\nfixed\n")
+
+	out, err := exec.Command("git", "-C", local, "worktree", "list", "--porcelain").CombinedOutput()
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(string(out), "worktree "), "group worktrees are removed")
+}
+
+func TestRunParallel_scope(t *testing.T) {
+	_, local := setupBareRepo(t)
+	// Uncommitted changes are part of what the groups start from.
+	require.NoError(t, os.WriteFile(filepath.Join(local, "a.go"), []byte("a\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(local, "b.go"), []byte("b\n"), 0644))
+	p := &Project{owner: "o", repo: "r", path: local}
+	// The agent fixes the file of its group; the one for b.go strays into c.go.
+	agent := AgentSection{Agent: "sh", Args: []string{"-c", `case "$0" in
+		*a.go*) echo fixed >> a.go ;;
+		*b.go*) echo fixed >> b.go; echo stray > c.go ;;
+		esac`}}
+	groups := splitReview(&FormattedReview{Comments: []FormattedComment{
+		{File: "a.go", Line: 1, Type: "issue", Content: "x"},
+		{File: "b.go", Line: 1, Type: "issue", Content: "y"},
+	}}, "file")
+
+	results := runParallel(context.Background(), p, agent, groups, 2)
+	require.NoError(t, results[0].Err)
+	require.ErrorContains(t, results[1].Err, "outside the group were changed: c.go")
+
+	data, err := os.ReadFile(filepath.Join(local, "a.go"))
+	require.NoError(t, err)
+	require.Equal(t, "a\nfixed\n", string(data))
+	data, err = os.ReadFile(filepath.Join(local, "b.go"))
+	require.NoError(t, err)
+	require.Equal(t, "b\n", string(data))
+	require.NoFileExists(t, filepath.Join(local, "c.go"))
+}
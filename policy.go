@@ -0,0 +1,54 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// ReviewPolicy decides which review comments are sent to the agent and in
+// which order.
+type ReviewPolicy struct {
+	// Types lists the comment types sent to the agent. Empty sends all types.
+	Types []string `yaml:"types,omitempty"`
+	// Priorities orders comments by type, lower values first. Types without a
+	// priority come after all prioritised ones.
+	Priorities map[string]int `yaml:"priorities,omitempty"`
+}
+
+var defaultReviewPolicy = ReviewPolicy{
+	Types:      []string{"issue", "suggestion", "note", "praise"},
+	Priorities: map[string]int{"issue": 0, "suggestion": 1, "note": 2, "praise": 3},
+}
+
+func (pol ReviewPolicy) allows(commentType string) bool {
+	if len(pol.Types) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(pol.Types, func(t string) bool { return strings.EqualFold(t, commentType) })
+}
+
+func (pol ReviewPolicy) priority(commentType string) int {
+	if p, ok := pol.Priorities[strings.ToLower(commentType)]; ok {
+		return p
+	}
+	lowest := -1
+	for _, p := range pol.Priorities {
+		lowest = max(lowest, p)
+	}
+	return lowest + 1
+}
+
+// Apply returns a copy of review holding only the comments allowed by the
+// policy, each with its priority set.
+func (pol ReviewPolicy) Apply(review *FormattedReview) *FormattedReview {
+	out := *review
+	out.Comments = nil
+	for _, c := range review.Comments {
+		if !pol.allows(c.Type) {
+			continue
+		}
+		c.Priority = pol.priority(c.Type)
+		out.Comments = append(out.Comments, c)
+	}
+	return &out
+}
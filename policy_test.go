@@ -0,0 +1,60 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewPolicy_Apply(t *testing.T) {
+	review := &FormattedReview{
+		CommitSha: "388e9be",
+		Comments: []FormattedComment{
+			{File: "main.go", Line: 3, Type: "praise", Content: "nice", Index: 0},
+			{File: "internal/x.go", Line: 5, Type: "note", Content: "hmm", Index: 1},
+			{File: "main.go", Line: 1, Type: "ISSUE", Content: "bug", Index: 2},
+			{File: "internal/x.go", Line: 9, Type: "suggestion", Content: "rename", Index: 3},
+			{File: "a.go", Line: 2, Type: "question", Content: "why?", Index: 4},
+		},
+	}
+
+	pol := ReviewPolicy{
+		Types:      []string{"issue", "suggestion", "question"},
+		Priorities: map[string]int{"issue": 0, "suggestion": 1},
+	}
+	out := pol.Apply(review)
+	require.Len(t, review.Comments, 5, "input must not be modified")
+	require.Len(t, out.Comments, 3)
+
+	contents := func(r *FormattedReview) []string {
+		slices.SortFunc(r.Comments, compareFormattedComment)
+		var s []string
+		for _, c := range r.Comments {
+			s = append(s, c.Content)
+		}
+		return s
+	}
+	require.Equal(t, []string{"bug", "rename", "why?"}, contents(out))
+	require.Equal(t, 2, out.Comments[2].Priority)
+}
+
+func TestReviewPolicy_defaultKeepsAllIssuesFirst(t *testing.T) {
+	review, err := LoadReview("testdata/projector_dd115dd_20260127_144528.json")
+	require.NoError(t, err)
+
+	out := defaultReviewPolicy.Apply(review)
+	require.Len(t, out.Comments, len(review.Comments))
+	slices.SortFunc(out.Comments, compareFormattedComment)
+	require.Equal(t, "issue", out.Comments[0].Type)
+	require.Equal(t, "issue", out.Comments[1].Type)
+	require.Equal(t, "suggestion", out.Comments[2].Type)
+	require.Equal(t, "note", out.Comments[len(out.Comments)-1].Type)
+}
+
+func TestReviewPolicy_emptyAllowsAll(t *testing.T) {
+	review := &FormattedReview{Comments: []FormattedComment{{Type: "praise"}, {Type: "issue"}}}
+	out := ReviewPolicy{}.Apply(review)
+	require.Len(t, out.Comments, 2)
+	require.Zero(t, out.Comments[0].Priority)
+}
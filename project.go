@@ -4,12 +4,20 @@ import (
 	"cmp"
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
 )
 
+// WorktreesConfig controls how branches are checked out.
+type WorktreesConfig struct {
+	// Enabled checks out each branch into its own git worktree, listed as a
+	// project of its own, instead of switching the branch of the clone.
+	Enabled bool `yaml:"enabled"`
+}
+
 type Worktree struct {
 	Owner string
 	Repo  string
@@ -30,20 +38,114 @@ type Project struct {
 	owner  string
 	path   string
 	branch string
+	// root is the path of the main clone when the project is a linked
+	// worktree of it.
+	root string
+
+	// config is the project's configuration when it has a .tcr.yaml and
+	// configErr the error loading it.
+	config    *AgentConfig
+	configErr error
+	// checks are the last results of the project's commands.
+	checks []*CheckResult
 
 	worktrees []*Worktree
 }
 
-func (p *Project) Title() string       { return fmt.Sprintf("%s/%s", p.owner, p.repo) }
+func (p *Project) Title() string {
+	if p.root != "" {
+		return fmt.Sprintf("%s – %s", p.fullName(), p.branch)
+	}
+	return p.fullName()
+}
+
+// fullName returns the owner/repo of p, which its worktrees share.
+func (p *Project) fullName() string { return fmt.Sprintf("%s/%s", p.owner, p.repo) }
+
 func (p *Project) Description() string {
+	desc := ""
 	if p.branch != "" {
-		return fmt.Sprintf("branch: %s", p.branch)
+		desc = fmt.Sprintf("branch: %s", p.branch)
+	}
+	if p.configErr != nil {
+		desc = strings.TrimPrefix(desc+" · invalid "+projectConfigName, " · ")
 	}
-	return ""
+	for _, r := range p.checks {
+		desc = strings.TrimPrefix(desc+" · "+r.Badge(), " · ")
+	}
+	return desc
 }
 func (p *Project) FilterValue() string { return p.Title() }
 
+// Config returns the configuration to use when acting on p.
+func (p *Project) Config() *AgentConfig {
+	if p.config != nil {
+		return p.config
+	}
+	return &cfg
+}
+
+// checkConfig returns the error loading the project's .tcr.yaml, if any.
+func (p *Project) checkConfig() error {
+	if p.configErr != nil {
+		return fmt.Errorf("%s: %w", p.Title(), p.configErr)
+	}
+	return nil
+}
+
+// approvedCommandsPath is the file holding the commands of the project's
+// .tcr.yaml the user approved, kept outside the repository.
+func (p *Project) approvedCommandsPath() string {
+	return filepath.Join(p.stateDir("config"), "approved")
+}
+
+// unapprovedCommands returns the commands the project's .tcr.yaml changes
+// from config.yaml when the user has not approved them yet.
+func (p *Project) unapprovedCommands() []string {
+	if p.config == nil {
+		return nil
+	}
+	changed := p.config.changedCommands(cfg)
+	if len(changed) == 0 {
+		return nil
+	}
+	approved, err := os.ReadFile(p.approvedCommandsPath())
+	if err == nil && string(approved) == strings.Join(changed, "\n") {
+		return nil
+	}
+	return changed
+}
+
+// approveCommands records that the user approved commands, as returned by
+// unapprovedCommands, until the .tcr.yaml or config.yaml changes them.
+func (p *Project) approveCommands(commands []string) error {
+	if err := os.MkdirAll(p.stateDir("config"), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(p.approvedCommandsPath(), []byte(strings.Join(commands, "\n")), 0644)
+}
+
+// stateDir returns the directory holding tcr state of the given kind for p.
+// A worktree shares the state of its main clone, whose records name the
+// branch they belong to.
+func (p *Project) stateDir(kind string) string {
+	main := p.mainPath()
+	return filepath.Join(filepath.Dir(main), stateDirName, filepath.Base(main), kind)
+}
+
+// mainPath returns the path of the main clone of p.
+func (p *Project) mainPath() string { return cmp.Or(p.root, p.path) }
+
+// worktreeDir returns the directory of the worktree of branch name, kept
+// with the state of the main clone.
+func (p *Project) worktreeDir(name string) string {
+	return filepath.Join(p.stateDir("worktrees"), url.PathEscape(name))
+}
+
 func (p *Project) Refresh(ctx context.Context) error {
+	p.config, p.configErr = loadProjectConfig(p.path)
+	// Missing badges are no reason to fail loading the project.
+	p.checks, _ = p.Checks()
 	branch, err := currentBranch(ctx, p.path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -58,23 +160,63 @@ func (p *Project) Refresh(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	paths, err := listWorktrees(ctx, p.path)
+	if err != nil {
+		return err
+	}
 	wts := make([]*Worktree, 0, len(branches))
 	for _, b := range branches {
-		wts = append(wts, &Worktree{Name: b, Path: p.path, Owner: p.owner, Repo: p.repo})
+		wts = append(wts, &Worktree{Name: b, Path: cmp.Or(paths[b], p.mainPath()), Owner: p.owner, Repo: p.repo})
 	}
 	slices.SortFunc(wts, compareWorktree)
 	p.worktrees = wts
 	return nil
 }
 
+// worktree returns the worktree of branch name, nil if there is no such
+// branch.
+func (p *Project) worktree(name string) *Worktree {
+	idx, found := slices.BinarySearchFunc(p.worktrees, &Worktree{Name: name}, compareWorktree)
+	if !found {
+		return nil
+	}
+	return p.worktrees[idx]
+}
+
+// AddWorktree checks out branch name. In worktree mode the branch gets a
+// worktree of its own unless it is checked out already; otherwise the clone
+// switches to it.
 func (p *Project) AddWorktree(ctx context.Context, name string) error {
-	if err := checkoutBranch(ctx, p.path, name); err != nil {
+	if !p.Config().Worktrees.Enabled {
+		if err := checkoutBranch(ctx, p.path, name); err != nil {
+			return err
+		}
+		return p.Refresh(ctx)
+	}
+	if wt := p.worktree(name); wt != nil && wt.Path != p.mainPath() {
+		return fmt.Errorf("%s is checked out in %s already", name, wt.Path)
+	}
+	if branch, err := currentBranch(ctx, p.mainPath()); err == nil && branch == name {
+		return fmt.Errorf("%s is checked out in %s already", name, p.mainPath())
+	}
+	dir := p.worktreeDir(name)
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	if err := addWorktree(ctx, p.mainPath(), dir, name); err != nil {
 		return err
 	}
 	return p.Refresh(ctx)
 }
 
+// DeleteWorktree deletes branch name, removing its worktree first if it has
+// one.
 func (p *Project) DeleteWorktree(ctx context.Context, name string) error {
+	if wt := p.worktree(name); wt != nil && wt.Path != p.mainPath() {
+		if err := removeWorktree(ctx, p.mainPath(), wt.Path); err != nil {
+			return err
+		}
+	}
 	if _, err := execute(ctx, p.path, "git", "branch", "-d", name); err != nil {
 		if _, err2 := execute(ctx, p.path, "git", "branch", "-D", name); err2 != nil {
 			return fmt.Errorf("delete branch %q: %w", name, err)
@@ -120,6 +262,28 @@ func LoadProject(ctx context.Context, path string) (*Project, error) {
 	return p, p.Refresh(ctx)
 }
 
+// loadProjectWorktrees loads the clone at path followed by a project for each
+// of its linked worktrees.
+func loadProjectWorktrees(ctx context.Context, path string) ([]*Project, error) {
+	p, err := LoadProject(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	projects := []*Project{p}
+	for _, wt := range p.worktrees {
+		if wt.Path == p.path {
+			continue
+		}
+		// Worktrees whose directory is gone are left to git worktree prune.
+		w := &Project{owner: p.owner, repo: p.repo, path: wt.Path, root: p.path}
+		if err := w.Refresh(ctx); err != nil || w.branch == "" {
+			continue
+		}
+		projects = append(projects, w)
+	}
+	return projects, nil
+}
+
 const maxConcurrency = 4
 
 func LoadProjects(ctx context.Context, workspace string) ([]*Project, error) {
@@ -129,8 +293,8 @@ func LoadProjects(ctx context.Context, workspace string) ([]*Project, error) {
 	}
 
 	type result struct {
-		project *Project
-		err     error
+		projects []*Project
+		err      error
 	}
 
 	ch := make(chan result, len(entries))
@@ -138,7 +302,7 @@ func LoadProjects(ctx context.Context, workspace string) ([]*Project, error) {
 	sem := make(chan struct{}, maxConcurrency)
 
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
 			continue
 		}
 		count++
@@ -146,18 +310,22 @@ func LoadProjects(ctx context.Context, workspace string) ([]*Project, error) {
 		go func() {
 			sem <- struct{}{}
 			defer func() { <-sem }()
-			p, err := LoadProject(ctx, filepath.Join(workspace, dirName))
-			ch <- result{project: p, err: err}
+			ps, err := loadProjectWorktrees(ctx, filepath.Join(workspace, dirName))
+			ch <- result{projects: ps, err: err}
 		}()
 	}
 
 	projects := make([]*Project, 0, count)
 	for range count {
 		r := <-ch
-		if r.err == nil && r.project != nil {
-			projects = append(projects, r.project)
+		if r.err == nil {
+			projects = append(projects, r.projects...)
 		}
 	}
+	// Worktrees follow the clone they belong to.
+	slices.SortFunc(projects, func(a, b *Project) int {
+		return cmp.Or(cmp.Compare(a.mainPath(), b.mainPath()), cmp.Compare(a.root, b.root), cmp.Compare(a.path, b.path))
+	})
 	return projects, nil
 }
 
@@ -11,7 +11,17 @@ type ProjectAction int
 const (
 	ProjectActionNone ProjectAction = iota
 	ProjectActionReview
+	ProjectActionDiffReview
+	ProjectActionHistory
+	ProjectActionJobs
+	ProjectActionLogs
+	ProjectActionRunChecks
+	ProjectActionChecks
+	ProjectActionRollback
+	ProjectActionCheckpoints
+	ProjectActionImport
 	ProjectActionInteract
+	ProjectActionPickAgent
 	ProjectActionCheckout
 	ProjectActionClone
 	ProjectActionDelete
@@ -24,16 +34,26 @@ type projectSelectedMsg struct {
 }
 
 type projectKeyMap struct {
-	Review   key.Binding
-	Interact key.Binding
-	Checkout key.Binding
-	Clone    key.Binding
-	Delete   key.Binding
-	Quit     key.Binding
+	Review      key.Binding
+	DiffReview  key.Binding
+	History     key.Binding
+	Jobs        key.Binding
+	Logs        key.Binding
+	RunChecks   key.Binding
+	Checks      key.Binding
+	Rollback    key.Binding
+	Checkpoints key.Binding
+	Import      key.Binding
+	Interact    key.Binding
+	PickAgent   key.Binding
+	Checkout    key.Binding
+	Clone       key.Binding
+	Delete      key.Binding
+	Quit        key.Binding
 }
 
 func (k projectKeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Review, k.Interact, k.Checkout, k.Clone, k.Delete, k.Quit}
+	return []key.Binding{k.Review, k.DiffReview, k.History, k.Jobs, k.Logs, k.RunChecks, k.Checks, k.Rollback, k.Checkpoints, k.Import, k.Interact, k.PickAgent, k.Checkout, k.Clone, k.Delete, k.Quit}
 }
 
 func (k projectKeyMap) FullHelp() [][]key.Binding {
@@ -42,12 +62,22 @@ func (k projectKeyMap) FullHelp() [][]key.Binding {
 
 func defaultProjectKeyMap() projectKeyMap {
 	return projectKeyMap{
-		Review:   key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "review")),
-		Interact: key.NewBinding(key.WithKeys("i", "enter"), key.WithHelp("i/enter", "interact")),
-		Checkout: key.NewBinding(key.WithKeys("b"), key.WithHelp("b", "branch")),
-		Clone:    key.NewBinding(key.WithKeys("c", "n"), key.WithHelp("c/n", "clone")),
-		Delete:   key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "delete")),
-		Quit:     key.NewBinding(key.WithKeys("q"), key.WithHelp("q", "quit")),
+		Review:      key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "review")),
+		DiffReview:  key.NewBinding(key.WithKeys("R"), key.WithHelp("R", "diff review")),
+		History:     key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "reviews")),
+		Jobs:        key.NewBinding(key.WithKeys("J"), key.WithHelp("J", "jobs")),
+		Logs:        key.NewBinding(key.WithKeys("L"), key.WithHelp("L", "agent logs")),
+		RunChecks:   key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "run checks")),
+		Checks:      key.NewBinding(key.WithKeys("T"), key.WithHelp("T", "check results")),
+		Rollback:    key.NewBinding(key.WithKeys("u"), key.WithHelp("u", "roll back last agent run")),
+		Checkpoints: key.NewBinding(key.WithKeys("C"), key.WithHelp("C", "checkpoints")),
+		Import:      key.NewBinding(key.WithKeys("P"), key.WithHelp("P", "PR comments")),
+		Interact:    key.NewBinding(key.WithKeys("i", "enter"), key.WithHelp("i/enter", "interact")),
+		PickAgent:   key.NewBinding(key.WithKeys("I"), key.WithHelp("I", "interact with…")),
+		Checkout:    key.NewBinding(key.WithKeys("b"), key.WithHelp("b", "branch")),
+		Clone:       key.NewBinding(key.WithKeys("c", "n"), key.WithHelp("c/n", "clone")),
+		Delete:      key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "delete")),
+		Quit:        key.NewBinding(key.WithKeys("q"), key.WithHelp("q", "quit")),
 	}
 }
 
@@ -85,10 +115,50 @@ func (p *ProjectList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if selected, ok := p.list.SelectedItem().(*Project); ok {
 				return p, func() tea.Msg { return projectSelectedMsg{action: ProjectActionReview, project: selected} }
 			}
+		case key.Matches(msg, p.keyMap.DiffReview):
+			if selected, ok := p.list.SelectedItem().(*Project); ok {
+				return p, func() tea.Msg { return projectSelectedMsg{action: ProjectActionDiffReview, project: selected} }
+			}
+		case key.Matches(msg, p.keyMap.History):
+			if selected, ok := p.list.SelectedItem().(*Project); ok {
+				return p, func() tea.Msg { return projectSelectedMsg{action: ProjectActionHistory, project: selected} }
+			}
+		case key.Matches(msg, p.keyMap.Jobs):
+			if selected, ok := p.list.SelectedItem().(*Project); ok {
+				return p, func() tea.Msg { return projectSelectedMsg{action: ProjectActionJobs, project: selected} }
+			}
+		case key.Matches(msg, p.keyMap.Logs):
+			if selected, ok := p.list.SelectedItem().(*Project); ok {
+				return p, func() tea.Msg { return projectSelectedMsg{action: ProjectActionLogs, project: selected} }
+			}
+		case key.Matches(msg, p.keyMap.RunChecks):
+			if selected, ok := p.list.SelectedItem().(*Project); ok {
+				return p, func() tea.Msg { return projectSelectedMsg{action: ProjectActionRunChecks, project: selected} }
+			}
+		case key.Matches(msg, p.keyMap.Checks):
+			if selected, ok := p.list.SelectedItem().(*Project); ok {
+				return p, func() tea.Msg { return projectSelectedMsg{action: ProjectActionChecks, project: selected} }
+			}
+		case key.Matches(msg, p.keyMap.Rollback):
+			if selected, ok := p.list.SelectedItem().(*Project); ok {
+				return p, func() tea.Msg { return projectSelectedMsg{action: ProjectActionRollback, project: selected} }
+			}
+		case key.Matches(msg, p.keyMap.Checkpoints):
+			if selected, ok := p.list.SelectedItem().(*Project); ok {
+				return p, func() tea.Msg { return projectSelectedMsg{action: ProjectActionCheckpoints, project: selected} }
+			}
+		case key.Matches(msg, p.keyMap.Import):
+			if selected, ok := p.list.SelectedItem().(*Project); ok {
+				return p, func() tea.Msg { return projectSelectedMsg{action: ProjectActionImport, project: selected} }
+			}
 		case key.Matches(msg, p.keyMap.Interact):
 			if selected, ok := p.list.SelectedItem().(*Project); ok {
 				return p, func() tea.Msg { return projectSelectedMsg{action: ProjectActionInteract, project: selected} }
 			}
+		case key.Matches(msg, p.keyMap.PickAgent):
+			if selected, ok := p.list.SelectedItem().(*Project); ok {
+				return p, func() tea.Msg { return projectSelectedMsg{action: ProjectActionPickAgent, project: selected} }
+			}
 		case key.Matches(msg, p.keyMap.Checkout):
 			if selected, ok := p.list.SelectedItem().(*Project); ok {
 				return p, func() tea.Msg { return projectSelectedMsg{action: ProjectActionCheckout, project: selected} }
@@ -110,6 +110,19 @@ func TestWorktree_FilterValue(t *testing.T) {
 	require.Equal(t, "feature-branch", wt.FilterValue())
 }
 
+// testProject returns a project in an empty directory that is no git
+// repository, with a copy of the global config changed by configure.
+func testProject(t *testing.T, configure ...func(c *AgentConfig)) *Project {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "repo")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	c := cfg.clone()
+	for _, f := range configure {
+		f(&c)
+	}
+	return &Project{owner: "o", repo: "r", path: dir, config: &c}
+}
+
 func setupBareRepo(t *testing.T) (remoteDir, localDir string) {
 	t.Helper()
 	remote := t.TempDir()
@@ -227,3 +240,111 @@ func TestProject_DeleteWorktree_deletesBranch(t *testing.T) {
 		require.NotEqual(t, "to-delete", wt.Name)
 	}
 }
+
+func TestProject_Title_worktree(t *testing.T) {
+	p := &Project{owner: "myowner", repo: "myrepo", branch: "feature", root: "/ws/myrepo"}
+	require.Equal(t, "myowner/myrepo – feature", p.Title())
+	require.Equal(t, "myowner/myrepo", p.fullName())
+}
+
+// worktreeTestProject returns a project of local with worktree mode enabled
+// in its committed .tcr.yaml.
+func worktreeTestProject(t *testing.T, local string) *Project {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(local, projectConfigName), []byte("worktrees:\n  enabled: true\n"), 0644))
+	for _, args := range [][]string{{"add", projectConfigName}, {"commit", "-q", "-m", "worktree mode"}} {
+		out, err := exec.Command("git", append([]string{"-C", local}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	p := &Project{owner: "o", repo: "r", path: local}
+	require.NoError(t, p.Refresh(context.Background()))
+	require.True(t, p.Config().Worktrees.Enabled)
+	return p
+}
+
+func TestProject_AddWorktree_worktreeMode(t *testing.T) {
+	_, local := setupBareRepo(t)
+	ctx := context.Background()
+	p := worktreeTestProject(t, local)
+
+	require.NoError(t, p.AddWorktree(ctx, "feature/x"))
+
+	dir := p.worktreeDir("feature/x")
+	require.Equal(t, "feature%2Fx", filepath.Base(dir))
+	require.Equal(t, dir, p.worktree("feature/x").Path)
+	require.Equal(t, local, p.worktree("main").Path)
+	branch, err := currentBranch(ctx, dir)
+	require.NoError(t, err)
+	require.Equal(t, "feature/x", branch)
+	// The clone stays on its branch.
+	branch, err = currentBranch(ctx, local)
+	require.NoError(t, err)
+	require.Equal(t, "main", branch)
+
+	require.Error(t, p.AddWorktree(ctx, "feature/x"))
+	require.Error(t, p.AddWorktree(ctx, "main"))
+}
+
+func TestProject_AddWorktree_worktreeModeTracksRemoteBranch(t *testing.T) {
+	_, local := setupBareRepo(t)
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"checkout", "-q", "-b", "remote-only"},
+		{"push", "-q", "-u", "origin", "remote-only"},
+		{"checkout", "-q", "main"},
+		{"branch", "-q", "-D", "remote-only"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", local}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	p := worktreeTestProject(t, local)
+
+	require.NoError(t, p.AddWorktree(ctx, "remote-only"))
+
+	out, err := exec.Command("git", "-C", p.worktreeDir("remote-only"), "rev-parse", "--abbrev-ref", "@{upstream}").CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.Equal(t, "origin/remote-only", strings.TrimSpace(string(out)))
+}
+
+func TestProject_DeleteWorktree_removesWorktree(t *testing.T) {
+	_, local := setupBareRepo(t)
+	ctx := context.Background()
+	p := worktreeTestProject(t, local)
+	require.NoError(t, p.AddWorktree(ctx, "feature"))
+	dir := p.worktree("feature").Path
+
+	require.NoError(t, p.DeleteWorktree(ctx, "feature"))
+
+	require.NoDirExists(t, dir)
+	branches, err := listBranches(ctx, local)
+	require.NoError(t, err)
+	require.NotContains(t, branches, "feature")
+}
+
+func TestLoadProjectWorktrees(t *testing.T) {
+	_, local := setupBareRepo(t)
+	ctx := context.Background()
+	p := worktreeTestProject(t, local)
+	require.NoError(t, p.AddWorktree(ctx, "feature"))
+	_, err := exec.Command("git", "-C", local, "remote", "set-url", "origin", "https://github.com/o/r.git").CombinedOutput()
+	require.NoError(t, err)
+
+	projects, err := loadProjectWorktrees(ctx, local)
+	require.NoError(t, err)
+	require.Len(t, projects, 2)
+	require.Equal(t, "o/r", projects[0].Title())
+	wt := projects[1]
+	require.Equal(t, "o/r – feature", wt.Title())
+	require.Equal(t, p.worktreeDir("feature"), wt.path)
+	require.Equal(t, local, wt.root)
+
+	// Checkpoints are kept per worktree.
+	_, err = createCheckpoint(ctx, wt, "agent")
+	require.NoError(t, err)
+	cps, err := listCheckpoints(ctx, local)
+	require.NoError(t, err)
+	require.Empty(t, cps)
+	cps, err = listCheckpoints(ctx, wt.path)
+	require.NoError(t, err)
+	require.Len(t, cps, 1)
+}
@@ -1,26 +1,42 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"cmp"
 	"fmt"
-	"slices"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // FormattedComment holds comment data with metadata for sorting and formatting
 type FormattedComment struct {
-	IsOldSide bool
-	File      string
-	Line      int
-	Type      string
-	Content   string
-	Index     int
-	CreatedAt time.Time
+	IsOldSide bool      `json:"is_old_side,omitempty"`
+	File      string    `json:"file"`
+	Line      int       `json:"line,omitempty"`
+	Type      string    `json:"type"`
+	Content   string    `json:"content"`
+	Index     int       `json:"index"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	// Context is the source code surrounding the commented line, if embedded.
+	Context string `json:"context,omitempty"`
+	// Priority ranks the comment by its type, lower values are listed first.
+	Priority int `json:"priority,omitempty"`
+	// Deleted marks an old-side comment whose line no longer exists in the
+	// working tree. NearLine is the closest remaining line, if any.
+	Deleted  bool `json:"deleted,omitempty"`
+	NearLine int  `json:"near_line,omitempty"`
+	// OldCommit is the commit an old-side Line refers to when it is not the
+	// reviewed commit, e.g. the base of a pull request.
+	OldCommit string `json:"old_commit,omitempty"`
 }
 
 func compareFormattedComment(a, b FormattedComment) int {
 	switch {
+	case a.Priority != b.Priority:
+		return cmp.Compare(a.Priority, b.Priority)
 	case len(a.File) > len(b.File):
 		return -1
 	case len(a.File) < len(b.File):
@@ -38,9 +54,16 @@ func compareFormattedComment(a, b FormattedComment) int {
 
 func (c FormattedComment) Location() string {
 	switch {
+	// Comment on the whole review
+	case c.File == "":
+		return "(general)"
 	// File-level comment
 	case c.Line == 0:
 		return fmt.Sprintf("`%s`", c.File)
+	case c.Deleted && c.NearLine > 0:
+		return fmt.Sprintf("`%s:~%d` (deleted, near line %d)", c.File, c.Line, c.NearLine)
+	case c.Deleted:
+		return fmt.Sprintf("`%s:~%d` (deleted)", c.File, c.Line)
 	case c.IsOldSide:
 		return fmt.Sprintf("`%s:~%d`", c.File, c.Line)
 	}
@@ -51,34 +74,121 @@ func (c FormattedComment) CommentType() string {
 	return fmt.Sprintf("**[%s]**", strings.ToUpper(c.Type))
 }
 
+// ReviewFile is a file that is part of the reviewed change.
+type ReviewFile struct {
+	Path     string `json:"path"`
+	Status   string `json:"status,omitempty"`
+	Reviewed bool   `json:"reviewed"`
+}
+
 type FormattedReview struct {
-	CommitSha string
-	Comments  []FormattedComment
+	CommitSha  string             `json:"commit_sha"`
+	Repository string             `json:"repository,omitempty"`
+	Comments   []FormattedComment `json:"comments"`
+	Files      []ReviewFile       `json:"files,omitempty"`
+	Notes      string             `json:"notes,omitempty"`
+	// Source names where the comments came from when not a local review, e.g. "github".
+	Source string `json:"source,omitempty"`
+	// Group and Scope are set on the parts of a review split for parallel
+	// agent runs: the group name and the only files the agent may change.
+	Group string   `json:"group,omitempty"`
+	Scope []string `json:"scope,omitempty"`
+	// Part and Parts number the chunks of a review split to fit the prompt budget.
+	Part  int `json:"part,omitempty"`
+	Parts int `json:"parts,omitempty"`
 }
 
-func (review *FormattedReview) String() string {
-	var output strings.Builder
+// ShortCommit returns the abbreviated commit SHA of the review.
+func (review *FormattedReview) ShortCommit() string { return shortSha(review.CommitSha) }
 
-	// Header
-	output.WriteString("I reviewed your code and have the following comments. Please address them.\n\n")
+// oldCommit returns the commit the old side of c refers to.
+func (review *FormattedReview) oldCommit(c FormattedComment) string {
+	return cmp.Or(c.OldCommit, review.CommitSha, "HEAD")
+}
 
-	// Commit line
-	shortHash := review.CommitSha
-	if len(shortHash) > 7 {
-		shortHash = shortHash[:7]
+// UnreviewedFiles returns the files the reviewer has not marked as reviewed.
+func (review *FormattedReview) UnreviewedFiles() []ReviewFile {
+	var files []ReviewFile
+	for _, f := range review.Files {
+		if !f.Reviewed {
+			files = append(files, f)
+		}
 	}
-	output.WriteString("Reviewing commit: " + shortHash + "\n\n")
+	return files
+}
 
-	// Comment type legend
-	output.WriteString("Comment types: ISSUE (problems to fix), SUGGESTION (improvements), NOTE (observations), PRAISE (positive feedback)\n\n")
+// String renders the review with the built-in prompt template.
+func (review *FormattedReview) String() string {
+	var output strings.Builder
+	if err := (promptFormatter{defaultPrompt}).Format(&output, review); err != nil {
+		return err.Error()
+	}
+	return output.String()
+}
 
-	slices.SortFunc(review.Comments, compareFormattedComment)
+var (
+	reviewCommitRe  = regexp.MustCompile(`^Reviewing commit: (\S+)`)
+	reviewCommentRe = regexp.MustCompile("^(\\d+)\\. \\*\\*\\[([A-Za-z]+)\\]\\*\\* `([^`]+)` - (.*)$")
+)
 
-	// Generate numbered list
-	for i, c := range review.Comments {
-		line := fmt.Sprintf("%d. %s %s:\n%s\n\n", i+1, c.CommentType(), c.Location(), c.Content)
-		output.WriteString(line)
+// parseLocation splits a rendered location such as `main.go:~18` into its parts.
+func parseLocation(loc string) (file string, line int, isOldSide bool) {
+	idx := strings.LastIndexByte(loc, ':')
+	if idx == -1 {
+		return loc, 0, false
+	}
+	num, isOldSide := strings.CutPrefix(loc[idx+1:], "~")
+	n, err := strconv.Atoi(num)
+	if err != nil {
+		return loc, 0, false
 	}
+	return loc[:idx], n, isOldSide
+}
 
-	return output.String()
+// ParseReviewMarkdown parses the Markdown export written by `tuicr --stdout`.
+// Lines following a comment that do not start a new comment are treated as
+// a continuation of its content.
+func ParseReviewMarkdown(data []byte) (*FormattedReview, error) {
+	review := &FormattedReview{}
+	var current *FormattedComment
+	flush := func() {
+		if current != nil {
+			current.Content = strings.TrimSpace(current.Content)
+			review.Comments = append(review.Comments, *current)
+			current = nil
+		}
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := reviewCommitRe.FindStringSubmatch(line); m != nil && current == nil {
+			review.CommitSha = m[1]
+			continue
+		}
+		if m := reviewCommentRe.FindStringSubmatch(line); m != nil {
+			flush()
+			file, n, isOldSide := parseLocation(m[3])
+			current = &FormattedComment{
+				IsOldSide: isOldSide,
+				File:      file,
+				Line:      n,
+				Type:      strings.ToLower(m[2]),
+				Content:   m[4],
+				Index:     len(review.Comments),
+			}
+			continue
+		}
+		if current != nil {
+			current.Content += "\n" + line
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("parse review: %w", err)
+	}
+	flush()
+	if review.CommitSha == "" && len(review.Comments) == 0 {
+		return nil, fmt.Errorf("no review found in output: %q", bytes.TrimSpace(data))
+	}
+	return review, nil
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/subcommands"
+)
+
+// readReview loads a review from a tuicr session file (.json) or a tuicr
+// Markdown export.
+func readReview(path string) (*FormattedReview, error) {
+	if filepath.Ext(path) == ".json" {
+		return LoadReview(path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReviewMarkdown(data)
+}
+
+type reviewCmd struct{ format string }
+
+func (*reviewCmd) Name() string     { return "review" }
+func (*reviewCmd) Synopsis() string { return "render a tuicr review in the given format" }
+func (*reviewCmd) Usage() string {
+	return "review [-format " + strings.Join(reviewFormats(), "|") + "] <session.json|export.md>\n"
+}
+func (r *reviewCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&r.format, "format", "", "review output format (default from config)")
+}
+func (r *reviewCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...any) subcommands.ExitStatus {
+	if f.NArg() != 1 {
+		f.Usage()
+		return subcommands.ExitUsageError
+	}
+	if err := setReviewFormat(r.format); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return subcommands.ExitUsageError
+	}
+	review, err := readReview(f.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	out, err := review.Format(cfg.Review.Format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	fmt.Print(out)
+	return subcommands.ExitSuccess
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadReview_matchesPrompt(t *testing.T) {
+	review, err := LoadReview("testdata/projector_388e9be_20260127_152442.json")
+	require.NoError(t, err)
+
+	want, err := os.ReadFile("testdata/prompt.md")
+	require.NoError(t, err)
+	require.Equal(t, string(want), review.String())
+}
+
+// TestLoadReview_matchesExport renders the session of every tuicr export in
+// testdata in the layout of the export.
+func TestLoadReview_matchesExport(t *testing.T) {
+	exports, err := filepath.Glob("testdata/projector_*.md")
+	require.NoError(t, err)
+	require.NotEmpty(t, exports)
+	for _, path := range exports {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			review, err := LoadReview(strings.TrimSuffix(path, ".md") + ".json")
+			require.NoError(t, err)
+			want, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			got, err := review.Format("tuicr")
+			require.NoError(t, err)
+			require.Equal(t, string(want), got)
+		})
+	}
+}
+
+func TestFormattedReview_String_sessionNotes(t *testing.T) {
+	s, err := LoadSession("testdata/projector_388e9be_20260127_152442.json")
+	require.NoError(t, err)
+	s.SessionNotes = "Keep the public API stable.\n"
+	for path, f := range s.Files {
+		f.Reviewed = true
+		s.Files[path] = f
+	}
+
+	out := s.Review().String()
+	require.Contains(t, out, "PRAISE (positive feedback)\n\nGeneral instructions:\nKeep the public API stable.\n\n1. **[SUGGESTION]**")
+	require.NotContains(t, out, "not yet reviewed")
+}
+
+func TestLoadReview_ordersComments(t *testing.T) {
+	review, err := LoadReview("testdata/projector_dd115dd_20260127_144528.json")
+	require.NoError(t, err)
+	require.Equal(t, "dd115dd5fcb06efcfc59a950891116e8387a6846", review.CommitSha)
+	require.Len(t, review.Comments, 7)
+
+	slices.SortFunc(review.Comments, compareFormattedComment)
+	locations := make([]string, len(review.Comments))
+	for i, c := range review.Comments {
+		locations[i] = c.Location()
+	}
+	require.Equal(t, []string{
+		"`pkg/registry/registry.go:127`",
+		"`pkg/registry/registry.go:110`",
+		"`pkg/cluster/gossip.go:38`",
+		"`pkg/cluster/gossip.go:25`",
+		"`pkg/cluster/gossip.go:24`",
+		"`main.go:102`",
+		"`main.go:85`",
+	}, locations)
+}
+
+func TestSession_Review_sameLineKeepsOrder(t *testing.T) {
+	s, err := LoadSession("testdata/projector_388e9be_20260127_152442.json")
+	require.NoError(t, err)
+
+	review := s.Review()
+	require.Len(t, review.Comments, 3)
+	require.Equal(t, 0, review.Comments[0].Line)
+	require.True(t, review.Comments[1].IsOldSide)
+	require.False(t, review.Comments[2].IsOldSide)
+	require.Less(t, review.Comments[1].Index, review.Comments[2].Index)
+}
+
+func TestParseReviewMarkdown(t *testing.T) {
+	data, err := os.ReadFile("testdata/projector_388e9be_20260127_152442.md")
+	require.NoError(t, err)
+
+	review, err := ParseReviewMarkdown(data)
+	require.NoError(t, err)
+	require.Equal(t, "388e9be", review.CommitSha)
+	require.Equal(t, []FormattedComment{
+		{File: "main.go", Type: "suggestion", Content: "Make it smaller", Index: 0},
+		{IsOldSide: true, File: "main.go", Line: 18, Type: "note", Content: "ok", Index: 1},
+		{File: "main.go", Line: 18, Type: "praise", Content: "Not ok", Index: 2},
+	}, review.Comments)
+
+	export, err := review.Format("tuicr")
+	require.NoError(t, err)
+	require.Equal(t, string(data), export)
+}
+
+func TestParseReviewMarkdown_multiline(t *testing.T) {
+	data := []byte("Reviewing commit: abc1234\n\n1. **[ISSUE]** `a/b.go:3` - first line\nsecond line\n\n2. **[NOTE]** `c.go` - other\n")
+
+	review, err := ParseReviewMarkdown(data)
+	require.NoError(t, err)
+	require.Len(t, review.Comments, 2)
+	require.Equal(t, "first line\nsecond line", review.Comments[0].Content)
+	require.Equal(t, "a/b.go", review.Comments[0].File)
+	require.Equal(t, 3, review.Comments[0].Line)
+	require.Equal(t, "c.go", review.Comments[1].File)
+}
+
+func TestParseReviewMarkdown_empty(t *testing.T) {
+	_, err := ParseReviewMarkdown([]byte("\n"))
+	require.Error(t, err)
+}
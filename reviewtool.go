@@ -0,0 +1,234 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/ssh"
+)
+
+const (
+	captureStdout  = "stdout"
+	captureSession = "session"
+	captureJSON    = "json"
+)
+
+type reviewCapturedMsg struct {
+	project  *Project
+	previous *ReviewRecord
+	review   *FormattedReview
+	err      error
+}
+
+// ReviewAdapter normalizes the output of a review tool into a FormattedReview.
+type ReviewAdapter interface {
+	// CapturesStdout reports whether the tool's stdout holds the review.
+	CapturesStdout() bool
+	// Collect builds the review once the tool has exited. started is the time
+	// the tool was launched and stdout what it wrote, if captured.
+	Collect(dir string, started time.Time, stdout []byte) (*FormattedReview, error)
+}
+
+func reviewAdapterFor(rc ReviewConfig) (ReviewAdapter, error) {
+	switch rc.Capture {
+	case captureStdout, "":
+		return stdoutAdapter{sessions: cmp.Or(rc.Path, defaultSessionGlob())}, nil
+	case captureSession:
+		if rc.Path == "" {
+			return nil, fmt.Errorf("review.path must be set to a session file glob for capture %q", rc.Capture)
+		}
+		return sessionAdapter{glob: rc.Path}, nil
+	case captureJSON:
+		if rc.Path == "" {
+			return nil, fmt.Errorf("review.path must be set to a JSON file for capture %q", rc.Capture)
+		}
+		return jsonAdapter{path: rc.Path}, nil
+	}
+	return nil, fmt.Errorf("unknown review capture %q (available: %s, %s, %s)", rc.Capture, captureStdout, captureSession, captureJSON)
+}
+
+// modTimeSlack allows for file systems with coarse modification times when
+// checking whether a file was written after the review tool started.
+const modTimeSlack = time.Second
+
+func writtenSince(info os.FileInfo, started time.Time) bool {
+	return !info.ModTime().Before(started.Add(-modTimeSlack))
+}
+
+// expandPath expands environment variables and a leading ~ in path and
+// resolves it relative to dir.
+func expandPath(dir, path string) string {
+	path = os.ExpandEnv(path)
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return path
+}
+
+// defaultSessionGlob matches the session files tuicr keeps in its data
+// directory.
+func defaultSessionGlob() string {
+	if runtime.GOOS == "darwin" {
+		return "~/Library/Application Support/tuicr/reviews/*.json"
+	}
+	return filepath.Join(cmp.Or(os.Getenv("XDG_DATA_HOME"), "~/.local/share"), "tuicr", "reviews", "*.json")
+}
+
+// newestSession returns the newest file matching the session glob that was
+// written since started.
+func newestSession(dir, glob string, started time.Time) (string, error) {
+	pattern := expandPath(dir, glob)
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return "", fmt.Errorf("review.path %q: %w", glob, err)
+	}
+	var newest string
+	var newestTime time.Time
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil || !writtenSince(info, started) {
+			continue
+		}
+		if newest == "" || info.ModTime().After(newestTime) {
+			newest, newestTime = m, info.ModTime()
+		}
+	}
+	if newest == "" {
+		return "", fmt.Errorf("no review session matching %s was written", pattern)
+	}
+	return newest, nil
+}
+
+// stdoutAdapter parses the Markdown export of `tuicr --stdout`. The export
+// has neither the session notes nor the reviewed state of the files; they
+// are taken from the session matching sessions that tuicr saved for the same
+// commit, if any.
+type stdoutAdapter struct{ sessions string }
+
+func (stdoutAdapter) CapturesStdout() bool { return true }
+
+func (a stdoutAdapter) Collect(dir string, started time.Time, stdout []byte) (*FormattedReview, error) {
+	review, err := ParseReviewMarkdown(stdout)
+	if err != nil || a.sessions == "" {
+		return review, err
+	}
+	// The session is optional: tuicr only saves it when asked to.
+	path, err := newestSession(dir, a.sessions, started)
+	if err != nil {
+		return review, nil
+	}
+	session, err := LoadReview(path)
+	if err != nil || review.CommitSha == "" || !strings.HasPrefix(session.CommitSha, review.CommitSha) {
+		return review, nil
+	}
+	review.CommitSha = session.CommitSha
+	review.Files = session.Files
+	review.Notes = session.Notes
+	return review, nil
+}
+
+// sessionAdapter loads the newest tuicr session file matching glob that was
+// written while the tool was running.
+type sessionAdapter struct{ glob string }
+
+func (sessionAdapter) CapturesStdout() bool { return false }
+
+func (a sessionAdapter) Collect(dir string, started time.Time, _ []byte) (*FormattedReview, error) {
+	path, err := newestSession(dir, a.glob, started)
+	if err != nil {
+		return nil, err
+	}
+	return LoadReview(path)
+}
+
+// jsonAdapter reads a review in the JSON review format from path.
+type jsonAdapter struct{ path string }
+
+func (jsonAdapter) CapturesStdout() bool { return false }
+
+func (a jsonAdapter) Collect(dir string, started time.Time, _ []byte) (*FormattedReview, error) {
+	path := expandPath(dir, a.path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("read review: %w", err)
+	}
+	if !writtenSince(info, started) {
+		return nil, fmt.Errorf("review file %s was not updated", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read review: %w", err)
+	}
+	var review FormattedReview
+	if err := json.Unmarshal(data, &review); err != nil {
+		return nil, fmt.Errorf("parse review %s: %w", path, err)
+	}
+	return &review, nil
+}
+
+// captureReview runs the configured review tool interactively in the project
+// directory and collects its review through the configured adapter. When the
+// tool's stdout holds the review, only stdout is redirected into a temporary
+// file while its TUI keeps using the terminal.
+// When the branch was reviewed before, only the changes since that review are shown.
+func captureReview(sess ssh.Session, p *Project) tea.Cmd {
+	fail := func(err error) tea.Cmd {
+		return func() tea.Msg { return reviewCapturedMsg{project: p, err: err} }
+	}
+	rc := p.Config().Review
+	adapter, err := reviewAdapterFor(rc)
+	if err != nil {
+		return fail(err)
+	}
+	prev, err := previousRound(p, p.branch)
+	if err != nil {
+		return fail(err)
+	}
+	args := append(slices.Clone(rc.Args), roundArgs(context.Background(), p, prev)...)
+	cmd := rc.Command
+
+	var outPath string
+	if adapter.CapturesStdout() {
+		f, err := os.CreateTemp("", "tcr-review-*")
+		if err != nil {
+			return fail(err)
+		}
+		outPath = f.Name()
+		_ = f.Close()
+		args = append([]string{"-c", `out=$1; shift; exec "$@" >"$out"`, "sh", outPath, cmd}, args...)
+		cmd = "sh"
+	}
+
+	started := time.Now()
+	callback := func(err error) tea.Msg {
+		if outPath != "" {
+			defer os.Remove(outPath)
+		}
+		if err != nil {
+			return reviewCapturedMsg{project: p, previous: prev, err: err}
+		}
+		var stdout []byte
+		if outPath != "" {
+			if stdout, err = os.ReadFile(outPath); err != nil {
+				return reviewCapturedMsg{project: p, previous: prev, err: err}
+			}
+		}
+		review, err := adapter.Collect(p.path, started, stdout)
+		return reviewCapturedMsg{project: p, previous: prev, review: review, err: err}
+	}
+	return execInteractive(sess, p.path, p.Config().environ(), callback, cmd, args...)
+}
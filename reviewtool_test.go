@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewAdapterFor(t *testing.T) {
+	a, err := reviewAdapterFor(ReviewConfig{})
+	require.NoError(t, err)
+	require.True(t, a.CapturesStdout())
+
+	a, err = reviewAdapterFor(ReviewConfig{Capture: captureSession, Path: "*.json"})
+	require.NoError(t, err)
+	require.False(t, a.CapturesStdout())
+
+	_, err = reviewAdapterFor(ReviewConfig{Capture: captureJSON})
+	require.Error(t, err)
+	_, err = reviewAdapterFor(ReviewConfig{Capture: "xml"})
+	require.Error(t, err)
+}
+
+func TestStdoutAdapter_Collect(t *testing.T) {
+	out, err := os.ReadFile("testdata/projector_388e9be_20260127_152442.md")
+	require.NoError(t, err)
+	review, err := stdoutAdapter{}.Collect("", time.Now(), out)
+	require.NoError(t, err)
+	require.NotEmpty(t, review.Comments)
+}
+
+func TestStdoutAdapter_Collect_session(t *testing.T) {
+	dir := t.TempDir()
+	out, err := os.ReadFile("testdata/projector_388e9be_20260127_152442.md")
+	require.NoError(t, err)
+	session, err := LoadSession("testdata/projector_388e9be_20260127_152442.json")
+	require.NoError(t, err)
+	session.SessionNotes = "Keep it short."
+	data, err := json.Marshal(session)
+	require.NoError(t, err)
+	started := time.Now()
+	a := stdoutAdapter{sessions: "*.json"}
+
+	review, err := a.Collect(dir, started, out)
+	require.NoError(t, err)
+	require.Empty(t, review.Files, "no session was saved")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.json"), []byte(`{"base_commit": "dd115dd5fcb06efcfc59a950891116e8387a6846", "session_notes": "Other"}`), 0o644))
+	review, err = a.Collect(dir, started, out)
+	require.NoError(t, err)
+	require.Empty(t, review.Notes, "sessions of other commits are ignored")
+	older := started.Add(-modTimeSlack / 2)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "other.json"), older, older))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "session.json"), data, 0o644))
+	review, err = a.Collect(dir, started, out)
+	require.NoError(t, err)
+	require.Equal(t, session.BaseCommit, review.CommitSha)
+	require.Equal(t, "Keep it short.", review.Notes)
+	require.Equal(t, []ReviewFile{{Path: "main.go", Status: "modified"}}, review.Files)
+	require.Len(t, review.Comments, 3)
+}
+
+func TestSessionAdapter_Collect(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile("testdata/projector_388e9be_20260127_152442.json")
+	require.NoError(t, err)
+	started := time.Now()
+	old := filepath.Join(dir, "old.json")
+	require.NoError(t, os.WriteFile(old, []byte("{}"), 0o644))
+	require.NoError(t, os.Chtimes(old, started.Add(-time.Hour), started.Add(-time.Hour)))
+
+	a := sessionAdapter{glob: "*.json"}
+	_, err = a.Collect(dir, started, nil)
+	require.Error(t, err, "sessions from before the run are ignored")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.json"), data, 0o644))
+	review, err := a.Collect(dir, started, nil)
+	require.NoError(t, err)
+	want, err := LoadReview("testdata/projector_388e9be_20260127_152442.json")
+	require.NoError(t, err)
+	require.Equal(t, want, review)
+}
+
+func TestJSONAdapter_Collect(t *testing.T) {
+	dir := t.TempDir()
+	review := &FormattedReview{
+		CommitSha: "abc",
+		Comments: []FormattedComment{
+			{File: "a.go", Line: 3, Type: "issue", Content: "fix", Index: 0},
+			{File: "b.go", Type: "note", Content: "look", Index: 1},
+		},
+	}
+	out, err := review.Format("json")
+	require.NoError(t, err)
+	started := time.Now()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "review.json"), []byte(out), 0o644))
+
+	got, err := jsonAdapter{path: "review.json"}.Collect(dir, started, nil)
+	require.NoError(t, err)
+	require.Equal(t, review.CommitSha, got.CommitSha)
+	require.Equal(t, review.Comments, got.Comments)
+
+	_, err = jsonAdapter{path: "review.json"}.Collect(dir, started.Add(time.Hour), nil)
+	require.Error(t, err, "stale review files are rejected")
+}
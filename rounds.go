@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// previousRound returns the latest review of the given branch, or nil if the
+// branch has not been reviewed yet. Reviews imported from elsewhere do not
+// count as rounds.
+func previousRound(p *Project, branch string) (*ReviewRecord, error) {
+	records, err := p.Reviews()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		if r.Branch == branch && r.Source == "" {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+// roundArgs returns the extra review tool arguments limiting the diff to the
+// changes since prev, substituting {base} in the configured range_args.
+// Without new commits the tool's default view of the uncommitted changes
+// already starts at prev.
+func roundArgs(ctx context.Context, p *Project, prev *ReviewRecord) []string {
+	rangeArgs := p.Config().Review.RangeArgs
+	if prev == nil || prev.CommitSha == "" || len(rangeArgs) == 0 {
+		return nil
+	}
+	head, err := revParse(ctx, p.path, "HEAD")
+	if err != nil || head == prev.CommitSha {
+		return nil
+	}
+	args := make([]string, len(rangeArgs))
+	for i, a := range rangeArgs {
+		args[i] = strings.ReplaceAll(a, "{base}", prev.CommitSha)
+	}
+	return args
+}
+
+// roundLineSlack is how far a comment may move between rounds, e.g. because
+// lines were added above it, and still count as the same comment.
+const roundLineSlack = 5
+
+// sameText reports whether a and b say the same, ignoring case and spacing.
+func sameText(a, b FormattedComment) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(a.Content), " "), strings.Join(strings.Fields(b.Content), " "))
+}
+
+// nearby reports whether a and b are comments of the same type on close
+// lines, as a reworded comment on unchanged code is.
+func nearby(a, b FormattedComment) bool {
+	if a.Line == 0 || b.Line == 0 {
+		return a.Line == b.Line && a.Type == b.Type
+	}
+	return a.Type == b.Type && max(a.Line-b.Line, b.Line-a.Line) <= roundLineSlack
+}
+
+// compareRounds splits the comments of the previous round into those raised
+// again in the current round and those that were resolved. A comment is
+// raised again by a comment on the same file with the same text or, failing
+// that, of the same type close to its line. Each current comment carries over
+// at most one previous comment.
+func compareRounds(prev, cur []FormattedComment) (carried, resolved []FormattedComment) {
+	used := make([]bool, len(cur))
+	match := func(p FormattedComment, same func(a, b FormattedComment) bool) bool {
+		for i, c := range cur {
+			if !used[i] && c.File == p.File && same(p, c) {
+				used[i] = true
+				return true
+			}
+		}
+		return false
+	}
+	found := make([]bool, len(prev))
+	for i, p := range prev {
+		found[i] = match(p, func(a, b FormattedComment) bool { return sameText(a, b) && nearby(a, b) })
+	}
+	for i, p := range prev {
+		found[i] = found[i] || match(p, sameText)
+	}
+	for i, p := range prev {
+		found[i] = found[i] || match(p, nearby)
+	}
+	for i, p := range prev {
+		if found[i] {
+			carried = append(carried, p)
+		} else {
+			resolved = append(resolved, p)
+		}
+	}
+	return carried, resolved
+}
+
+// startRound numbers r as the round following prev and records which of the
+// previous comments were carried over or resolved.
+func (r *ReviewRecord) startRound(prev *ReviewRecord) {
+	if prev == nil {
+		r.Round = 1
+		return
+	}
+	r.Round = max(prev.Round, 1) + 1
+	r.PreviousSha = prev.CommitSha
+	r.CarriedOver, r.Resolved = compareRounds(prev.Comments, r.Comments)
+}
+
+// RoundSummary describes how the round relates to the previous one.
+func (r *ReviewRecord) RoundSummary() string {
+	if r.Round <= 1 {
+		return ""
+	}
+	var s strings.Builder
+	fmt.Fprintf(&s, "Round %d (since %s)\n\n", r.Round, shortSha(r.PreviousSha))
+	write := func(title string, comments []FormattedComment) {
+		fmt.Fprintf(&s, "%s round %d: %d\n", title, r.Round-1, len(comments))
+		for _, c := range comments {
+			fmt.Fprintf(&s, "- %s %s: %s\n", c.CommentType(), c.Location(), c.Content)
+		}
+		s.WriteString("\n")
+	}
+	write("Carried over from", r.CarriedOver)
+	write("Resolved since", r.Resolved)
+	return s.String()
+}
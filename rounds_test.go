@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareRounds(t *testing.T) {
+	prev := []FormattedComment{
+		{File: "main.go", Line: 10, Type: "issue", Content: "Handle the error"},
+		{File: "main.go", Line: 20, Type: "note", Content: "Rename this"},
+		{File: "util.go", Line: 3, Type: "issue", Content: "Handle the error"},
+	}
+	cur := []FormattedComment{
+		{File: "main.go", Line: 12, Type: "issue", Content: "handle the error "},
+		{File: "app.go", Line: 1, Type: "issue", Content: "New problem"},
+	}
+
+	carried, resolved := compareRounds(prev, cur)
+	require.Equal(t, []FormattedComment{prev[0]}, carried)
+	require.Equal(t, []FormattedComment{prev[1], prev[2]}, resolved)
+}
+
+func TestReviewRecord_startRound(t *testing.T) {
+	first := &ReviewRecord{Comments: []FormattedComment{{File: "a.go", Content: "x"}}}
+	first.startRound(nil)
+	require.Equal(t, 1, first.Round)
+	require.Empty(t, first.RoundSummary())
+
+	first.CommitSha = "388e9be2840309204aef7b82c3f3e4d5985dfa7f"
+	second := &ReviewRecord{Comments: []FormattedComment{{File: "b.go", Content: "y"}}}
+	second.startRound(first)
+	require.Equal(t, 2, second.Round)
+	require.Equal(t, first.CommitSha, second.PreviousSha)
+	require.Empty(t, second.CarriedOver)
+	require.Len(t, second.Resolved, 1)
+	require.Contains(t, second.RoundSummary(), "Round 2 (since 388e9be)")
+	require.Contains(t, second.RoundSummary(), "Resolved since round 1: 1")
+}
+
+func TestPreviousRound_matchesBranch(t *testing.T) {
+	_, local := setupBareRepo(t)
+	p := &Project{owner: "o", repo: "r", path: local}
+	require.NoError(t, p.SaveReview(&ReviewRecord{ID: "1", Branch: "main", Round: 1}))
+	require.NoError(t, p.SaveReview(&ReviewRecord{ID: "2", Branch: "feature", Round: 1}))
+	require.NoError(t, p.SaveReview(&ReviewRecord{ID: "3", Branch: "main", Round: 2}))
+
+	prev, err := previousRound(p, "main")
+	require.NoError(t, err)
+	require.Equal(t, "3", prev.ID)
+
+	prev, err = previousRound(p, "other")
+	require.NoError(t, err)
+	require.Nil(t, prev)
+}
+
+func TestCompareRounds_matchesLocation(t *testing.T) {
+	prev := []FormattedComment{
+		{File: "main.go", Line: 10, Type: "issue", Content: "Handle the error"},
+		{File: "main.go", Line: 40, Type: "issue", Content: "Handle the error"},
+		{File: "main.go", Line: 60, Type: "note", Content: "Rename this"},
+		{File: "main.go", Type: "suggestion", Content: "Split the file"},
+	}
+	cur := []FormattedComment{
+		// Moved down by new lines and reworded.
+		{File: "main.go", Line: 13, Type: "issue", Content: "The error is still ignored"},
+		{File: "main.go", Line: 42, Type: "issue", Content: "handle  the error"},
+		// Same place, different kind of comment.
+		{File: "main.go", Line: 60, Type: "issue", Content: "This leaks"},
+		{File: "main.go", Type: "suggestion", Content: "Still too long"},
+	}
+
+	carried, resolved := compareRounds(prev, cur)
+	require.Equal(t, []FormattedComment{prev[0], prev[1], prev[3]}, carried)
+	require.Equal(t, []FormattedComment{prev[2]}, resolved)
+}
+
+func TestRoundArgs_rangeArgs(t *testing.T) {
+	_, local := setupBareRepo(t)
+	ctx := context.Background()
+	c := defaultConfig.clone()
+	p := &Project{owner: "o", repo: "r", path: local, config: &c}
+	prev := &ReviewRecord{CommitSha: "388e9be2840309204aef7b82c3f3e4d5985dfa7f"}
+	require.Equal(t, []string{"-r", prev.CommitSha + "..HEAD"}, roundArgs(ctx, p, prev), "tuicr reviews the range by default")
+
+	c.Review.RangeArgs = nil
+	require.Nil(t, roundArgs(ctx, p, prev))
+}
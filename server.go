@@ -46,9 +46,19 @@ func SlogMiddleware() wish.Middleware {
 	}
 }
 
-func pullMain(ctx context.Context, projects []*Project) error {
+// pullMain pulls the worktrees of projects. Worktrees for which busy reports
+// running jobs are left for the next pull so HEAD does not move under them.
+func pullMain(ctx context.Context, projects []*Project, busy func(path string) bool) error {
 	for _, p := range projects {
+		// The worktrees of a clone are pulled along with it.
+		if p.root != "" {
+			continue
+		}
 		for _, wt := range p.worktrees {
+			if busy(wt.Path) {
+				slog.Info("skip pull while jobs run", "path", wt.Path)
+				continue
+			}
 			if err := pull(ctx, wt.Path); err != nil {
 				return err
 			}
@@ -63,6 +73,8 @@ type Server struct {
 	password  string
 	workspace string
 	interval  time.Duration
+	format    string
+	jobs      *JobQueue
 }
 
 func (s *Server) passkey() string {
@@ -76,11 +88,15 @@ func (s *Server) Start(ctx context.Context) error {
 	if err := bootstrapWorkspace(s.workspace); err != nil {
 		return err
 	}
+	if s.jobs == nil {
+		// Jobs outlive the sessions that queued them.
+		s.jobs = NewJobQueue()
+	}
 	options := []ssh.Option{
 		wish.WithAddress(s.host + ":" + strconv.Itoa(s.port)),
 		ssh.AllocatePty(),
 		wish.WithMiddleware(
-			bubbletea.Middleware(NewTeaHandler(s.workspace)),
+			bubbletea.Middleware(NewTeaHandler(s.workspace, s.jobs)),
 			activeterm.Middleware(),
 			SlogMiddleware(),
 		),
@@ -116,7 +132,7 @@ func (s *Server) Start(ctx context.Context) error {
 				if err != nil {
 					slog.Error(err.Error())
 				}
-				if err := pullMain(tCtx, projects); err != nil {
+				if err := pullMain(tCtx, projects, s.jobs.Busy); err != nil {
 					slog.Error(err.Error())
 				}
 				cancel()
@@ -146,9 +162,14 @@ func (s *Server) SetFlags(f *flag.FlagSet) {
 	home, _ := os.UserHomeDir()
 	ws := filepath.Join(home, ".local", "share", "tcr")
 	f.StringVar(&s.workspace, "workspace", ws, "dir for git worktree")
+	f.StringVar(&s.format, "format", "", "review prompt format (default from config)")
 }
 
 func (s *Server) Execute(ctx context.Context, f *flag.FlagSet, _ ...any) subcommands.ExitStatus {
+	if err := setReviewFormat(s.format); err != nil {
+		slog.Error(err.Error())
+		return subcommands.ExitUsageError
+	}
 	if err := s.Start(ctx); err != nil {
 		return subcommands.ExitFailure
 	}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullMain(t *testing.T) {
+	ctx := context.Background()
+	remote, local := setupBareRepo(t)
+	other := filepath.Join(t.TempDir(), "other")
+	gitOutput(t, filepath.Dir(other), "clone", "-q", remote, other)
+	require.NoError(t, os.WriteFile(filepath.Join(other, "new.txt"), []byte("new"), 0644))
+	gitOutput(t, other, "add", "new.txt")
+	gitOutput(t, other, "-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qm", "new")
+	gitOutput(t, other, "push", "-q")
+
+	head := gitOutput(t, local, "rev-parse", "HEAD")
+	p := &Project{owner: "o", repo: "r", path: local, worktrees: []*Worktree{{Name: "main", Path: local}}}
+	require.NoError(t, pullMain(ctx, []*Project{p}, func(string) bool { return true }))
+	require.Equal(t, head, gitOutput(t, local, "rev-parse", "HEAD"), "busy worktrees are not pulled")
+
+	require.NoError(t, pullMain(ctx, []*Project{p}, func(string) bool { return false }))
+	require.Equal(t, gitOutput(t, other, "rev-parse", "HEAD"), gitOutput(t, local, "rev-parse", "HEAD"))
+}
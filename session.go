@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SessionComment is a single comment left in a tuicr review session.
+type SessionComment struct {
+	ID          string    `json:"id"`
+	Content     string    `json:"content"`
+	CommentType string    `json:"comment_type"`
+	CreatedAt   time.Time `json:"created_at"`
+	Side        string    `json:"side,omitempty"`
+}
+
+// IsOldSide reports whether the comment refers to the base commit side of the diff.
+func (c SessionComment) IsOldSide() bool { return c.Side == "old" }
+
+// SessionFile is the review state of one file in a tuicr session.
+type SessionFile struct {
+	Path         string                   `json:"path"`
+	Reviewed     bool                     `json:"reviewed"`
+	Status       string                   `json:"status"`
+	FileComments []SessionComment         `json:"file_comments"`
+	LineComments map[int][]SessionComment `json:"line_comments"`
+}
+
+// Session is a tuicr review session as persisted to disk.
+type Session struct {
+	ID           string                 `json:"id"`
+	Version      string                 `json:"version"`
+	RepoPath     string                 `json:"repo_path"`
+	BaseCommit   string                 `json:"base_commit"`
+	CreatedAt    time.Time              `json:"created_at"`
+	UpdatedAt    time.Time              `json:"updated_at"`
+	Files        map[string]SessionFile `json:"files"`
+	SessionNotes string                 `json:"session_notes"`
+}
+
+// Paths returns the file paths of the session in lexical order.
+func (s *Session) Paths() []string {
+	paths := make([]string, 0, len(s.Files))
+	for path := range s.Files {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// Review converts the session into a FormattedReview.
+// Comments are indexed in a stable order (file path, file comments, then line
+// comments by line) so that comments on the same line keep their original order.
+func (s *Session) Review() *FormattedReview {
+	review := &FormattedReview{CommitSha: s.BaseCommit, Notes: strings.TrimSpace(s.SessionNotes)}
+	index := 0
+	add := func(file string, line int, c SessionComment) {
+		review.Comments = append(review.Comments, FormattedComment{
+			IsOldSide: c.IsOldSide(),
+			File:      file,
+			Line:      line,
+			Type:      c.CommentType,
+			Content:   c.Content,
+			Index:     index,
+			CreatedAt: c.CreatedAt,
+		})
+		index++
+	}
+	for _, path := range s.Paths() {
+		f := s.Files[path]
+		if f.Path == "" {
+			f.Path = path
+		}
+		review.Files = append(review.Files, ReviewFile{Path: f.Path, Status: f.Status, Reviewed: f.Reviewed})
+		for _, c := range f.FileComments {
+			add(f.Path, 0, c)
+		}
+		lines := make([]int, 0, len(f.LineComments))
+		for line := range f.LineComments {
+			lines = append(lines, line)
+		}
+		slices.Sort(lines)
+		for _, line := range lines {
+			for _, c := range f.LineComments[line] {
+				add(f.Path, line, c)
+			}
+		}
+	}
+	return review
+}
+
+// LoadSession reads a tuicr session file.
+func LoadSession(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read session %s: %w", path, err)
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse session %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// LoadReview reads a tuicr session file and converts it into a FormattedReview.
+func LoadReview(path string) (*FormattedReview, error) {
+	s, err := LoadSession(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.Review(), nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// stateDirName is the directory inside the workspace where tcr keeps its own
+// per-project state. It is hidden so LoadProjects does not treat it as a project.
+const stateDirName = ".tcr"
+
+// newRecordID returns a sortable, file-name safe identifier for t.
+func newRecordID(t time.Time) string {
+	return t.UTC().Format("20060102-150405.000000000")
+}
+
+// recordStore persists JSON records in dir, one file per record.
+type recordStore[T any] struct{ dir string }
+
+func (s recordStore[T]) path(id string) string { return filepath.Join(s.dir, id+".json") }
+
+func (s recordStore[T]) Save(id string, v *T) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("create %s: %w", s.dir, err)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal record %s: %w", id, err)
+	}
+	tmp := s.path(id) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write record %s: %w", id, err)
+	}
+	return os.Rename(tmp, s.path(id))
+}
+
+func (s recordStore[T]) Load(id string) (*T, error) {
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		return nil, err
+	}
+	v := new(T)
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("parse record %s: %w", id, err)
+	}
+	return v, nil
+}
+
+// List returns all records in the store, newest first.
+func (s recordStore[T]) List() ([]*T, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, e := range entries {
+		if id, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	slices.Reverse(ids)
+	records := make([]*T, 0, len(ids))
+	for _, id := range ids {
+		v, err := s.Load(id)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, v)
+	}
+	return records, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordStore_roundTrip(t *testing.T) {
+	store := recordStore[ReviewRecord]{dir: t.TempDir()}
+
+	records, err := store.List()
+	require.NoError(t, err)
+	require.Empty(t, records)
+
+	first := time.Date(2026, 1, 27, 15, 24, 42, 0, time.UTC)
+	older := &ReviewRecord{ID: newRecordID(first), CommitSha: "388e9be", CreatedAt: first}
+	newer := &ReviewRecord{ID: newRecordID(first.Add(time.Minute)), CommitSha: "dd115dd", CreatedAt: first.Add(time.Minute)}
+	require.NoError(t, store.Save(older.ID, older))
+	require.NoError(t, store.Save(newer.ID, newer))
+
+	records, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "dd115dd", records[0].CommitSha)
+	require.Equal(t, "388e9be", records[1].CommitSha)
+
+	loaded, err := store.Load(older.ID)
+	require.NoError(t, err)
+	require.True(t, first.Equal(loaded.CreatedAt))
+}
+
+func TestProject_stateDir(t *testing.T) {
+	p := &Project{path: "/ws/repo"}
+	require.Equal(t, "/ws/.tcr/repo/reviews", p.stateDir("reviews"))
+
+	// A worktree keeps its state with the main clone.
+	wt := &Project{path: p.worktreeDir("feature/x"), root: p.path, branch: "feature/x"}
+	require.Equal(t, "/ws/.tcr/repo/worktrees/feature%2Fx", wt.path)
+	require.Equal(t, "/ws/.tcr/repo/reviews", wt.stateDir("reviews"))
+	require.Equal(t, "/ws/.tcr/repo/checks/worktrees/feature%2Fx", wt.checkStore().dir)
+	require.Equal(t, "/ws/.tcr/repo/checks", p.checkStore().dir)
+}
+
+func TestProject_SaveReview(t *testing.T) {
+	_, local := setupBareRepo(t)
+	p := &Project{owner: "o", repo: "r", path: local}
+	require.NoError(t, p.SaveReview(&ReviewRecord{ID: "1"}))
+
+	records, err := p.Reviews()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TCRConfig enables test && commit || revert after non-interactive agent
+// runs: the changes of the agent are committed if commands.test passes and
+// reverted otherwise.
+type TCRConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// OutputLines is the number of trailing lines of test output kept.
+	OutputLines int `yaml:"output_lines"`
+}
+
+var defaultTCRConfig = TCRConfig{OutputLines: 20}
+
+type TCROutcome string
+
+const (
+	TCRCommitted TCROutcome = "committed"
+	TCRReverted  TCROutcome = "reverted"
+	TCRUnchanged TCROutcome = "unchanged"
+)
+
+// TCRResult is the outcome of test && commit || revert after an agent run.
+type TCRResult struct {
+	Outcome TCROutcome `json:"outcome"`
+	// Tested reports whether the test command ran; it does not when the
+	// agent failed or changed nothing.
+	Tested     bool   `json:"tested"`
+	Passed     bool   `json:"passed"`
+	Base       string `json:"base"`
+	Commit     string `json:"commit,omitempty"`
+	TestOutput string `json:"test_output,omitempty"`
+}
+
+func (r *TCRResult) Summary() string {
+	switch {
+	case r.Outcome == TCRUnchanged:
+		return "no changes"
+	case r.Outcome == TCRCommitted:
+		return "tests passed, committed " + shortSha(r.Commit)
+	case r.Tested && r.Passed:
+		return "commit failed, reverted to " + shortSha(r.Base)
+	case r.Tested:
+		return "tests failed, reverted to " + shortSha(r.Base)
+	}
+	return "agent failed, reverted to " + shortSha(r.Base)
+}
+
+// tail returns the last n lines of s.
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isDirty reports whether the working tree at dir has uncommitted changes.
+func isDirty(ctx context.Context, dir string) (bool, error) {
+	out, err := execute(ctx, dir, "git", "status", "--porcelain")
+	if err != nil {
+		return false, fmt.Errorf("git status: %w: %s", err, out)
+	}
+	return len(strings.TrimSpace(string(out))) > 0, nil
+}
+
+// beginTCR returns the commit to revert p to after the agent run. The
+// working tree must be clean, as reverting discards every change.
+func beginTCR(ctx context.Context, p *Project) (string, error) {
+	if p.Commands().Test == "" {
+		return "", fmt.Errorf("tcr mode needs a test command; none is configured in commands.test or detected")
+	}
+	dirty, err := isDirty(ctx, p.path)
+	if err != nil {
+		return "", err
+	}
+	if dirty {
+		return "", fmt.Errorf("%s has uncommitted changes; commit or stash them before running in tcr mode", p.Title())
+	}
+	return revParse(ctx, p.path, "HEAD")
+}
+
+// finishTCR tests the changes the agent made on top of base and commits them
+// if the tests pass. Otherwise, or if the agent failed or the commit fails, p
+// is reset to base.
+func finishTCR(ctx context.Context, p *Project, base string, r *ReviewRecord, agentErr error) (*TCRResult, error) {
+	result := &TCRResult{Base: base}
+	if agentErr != nil {
+		result.Outcome = TCRReverted
+		return result, revertTo(ctx, p.path, base)
+	}
+	dirty, err := isDirty(ctx, p.path)
+	if err != nil {
+		return nil, err
+	}
+	head, err := revParse(ctx, p.path, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if !dirty && head == base {
+		result.Outcome = TCRUnchanged
+		return result, nil
+	}
+
+	// The run is recorded as the project's last test result; failing to
+	// store it does not affect the outcome.
+	check, err := runCheck(ctx, p, "test")
+	if check == nil {
+		return nil, errors.Join(err, revertTo(ctx, p.path, base))
+	}
+	result.Tested = true
+	result.Passed = check.Passed
+	result.TestOutput = tail(check.Output, p.Config().TCR.OutputLines)
+	if !result.Passed {
+		result.Outcome = TCRReverted
+		return result, revertTo(ctx, p.path, base)
+	}
+
+	result.Outcome = TCRCommitted
+	result.Commit = head
+	if dirty {
+		if err := commitAll(ctx, p.path, tcrCommitMessage(r)); err != nil {
+			result.Outcome = TCRReverted
+			return result, errors.Join(err, revertTo(ctx, p.path, base))
+		}
+		if result.Commit, err = revParse(ctx, p.path, "HEAD"); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// commitAll commits all changes in dir with message. Without a git identity
+// configured, as on a server, the commit is made as tcr.
+func commitAll(ctx context.Context, dir, message string) error {
+	if _, err := git(ctx, dir, nil, "add", "-A"); err != nil {
+		return err
+	}
+	var env []string
+	for _, ident := range []string{"GIT_AUTHOR_IDENT", "GIT_COMMITTER_IDENT"} {
+		if _, err := git(ctx, dir, nil, "var", ident); err != nil {
+			env = checkpointIdent
+		}
+	}
+	_, err := git(ctx, dir, env, "commit", "-q", "-m", message)
+	return err
+}
+
+// withTCR runs fix, the agent run on r, and in tcr mode commits or reverts
+// its changes depending on the outcome of the tests.
+func withTCR(ctx context.Context, p *Project, r *ReviewRecord, fix func() ([]byte, error)) ([]byte, *TCRResult, error) {
+	if !p.Config().TCR.Enabled {
+		output, err := fix()
+		return output, nil, err
+	}
+	base, err := beginTCR(ctx, p)
+	if err != nil {
+		return nil, nil, err
+	}
+	output, err := fix()
+	// The changes of a canceled agent are reverted, too.
+	result, tcrErr := finishTCR(context.WithoutCancel(ctx), p, base, r, err)
+	switch {
+	case tcrErr != nil && err != nil:
+		err = fmt.Errorf("%w; %v", err, tcrErr)
+	case tcrErr != nil:
+		err = tcrErr
+	case err == nil && result.Outcome == TCRReverted:
+		err = errors.New("tests failed, changes reverted")
+	}
+	return output, result, err
+}
+
+// revertTo discards all commits and changes made on top of base.
+func revertTo(ctx context.Context, dir, base string) error {
+	if out, err := execute(ctx, dir, "git", "reset", "-q", "--hard", base); err != nil {
+		return fmt.Errorf("git reset: %w: %s", err, out)
+	}
+	if out, err := execute(ctx, dir, "git", "clean", "-q", "-fd"); err != nil {
+		return fmt.Errorf("git clean: %w: %s", err, out)
+	}
+	return nil
+}
+
+// tcrCommitMessage describes the review comments addressed by a commit.
+func tcrCommitMessage(r *ReviewRecord) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "Address %d review comments", len(r.Comments))
+	if r.CommitSha != "" {
+		fmt.Fprintf(&s, " on %s", shortSha(r.CommitSha))
+	}
+	s.WriteString("\n\n")
+	for _, c := range r.Comments {
+		line, _, _ := strings.Cut(strings.TrimSpace(c.Content), "\n")
+		if runes := []rune(line); len(runes) > 72 {
+			line = string(runes[:71]) + "…"
+		}
+		if c.File == "" {
+			fmt.Fprintf(&s, "- %s\n", line)
+		} else if c.Line > 0 {
+			fmt.Fprintf(&s, "- %s:%d: %s\n", c.File, c.Line, line)
+		} else {
+			fmt.Fprintf(&s, "- %s: %s\n", c.File, line)
+		}
+	}
+	fmt.Fprintf(&s, "\nReview: %s\n", r.ID)
+	return s.String()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func tcrTestProject(t *testing.T) *Project {
+	t.Helper()
+	_, local := setupBareRepo(t)
+	c := cfg.clone()
+	c.Commands.Test = `echo "running tests"; test ! -e broken`
+	c.TCR = TCRConfig{Enabled: true, OutputLines: 1}
+	return &Project{owner: "o", repo: "r", path: local, config: &c}
+}
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := execute(context.Background(), dir, "git", args...)
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+func TestWithTCR(t *testing.T) {
+	ctx := context.Background()
+	p := tcrTestProject(t)
+	r := &ReviewRecord{ID: "r1", CommitSha: "abcdef0123", Comments: []FormattedComment{
+		{File: "main.go", Line: 3, Content: "Handle the error\nsecond line"},
+	}}
+	write := func(name string) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			return []byte("done"), os.WriteFile(filepath.Join(p.path, name), []byte("x"), 0644)
+		}
+	}
+	base := gitOutput(t, p.path, "rev-parse", "HEAD")
+
+	output, result, err := withTCR(ctx, p, r, write("fixed.go"))
+	require.NoError(t, err)
+	require.Equal(t, "done", string(output))
+	require.Equal(t, TCRCommitted, result.Outcome)
+	require.True(t, result.Passed)
+	require.Equal(t, "running tests", result.TestOutput)
+	require.Equal(t, gitOutput(t, p.path, "rev-parse", "HEAD"), result.Commit)
+	require.Equal(t, "Address 1 review comments on abcdef0\n\n- main.go:3: Handle the error\n\nReview: r1", gitOutput(t, p.path, "log", "-1", "--format=%B"))
+	require.Equal(t, base, gitOutput(t, p.path, "rev-parse", "HEAD^"))
+
+	base = result.Commit
+	_, result, err = withTCR(ctx, p, r, write("broken"))
+	require.ErrorContains(t, err, "tests failed, changes reverted")
+	require.Equal(t, TCRReverted, result.Outcome)
+	require.True(t, result.Tested)
+	require.Equal(t, "tests failed, reverted to "+base[:7], result.Summary())
+	require.NoFileExists(t, filepath.Join(p.path, "broken"))
+	require.Equal(t, base, gitOutput(t, p.path, "rev-parse", "HEAD"))
+
+	_, result, err = withTCR(ctx, p, r, func() ([]byte, error) {
+		_, _ = write("half.go")()
+		return nil, errors.New("agent crashed")
+	})
+	require.ErrorContains(t, err, "agent crashed")
+	require.False(t, result.Tested)
+	require.NoFileExists(t, filepath.Join(p.path, "half.go"))
+
+	_, result, err = withTCR(ctx, p, r, func() ([]byte, error) { return nil, nil })
+	require.NoError(t, err)
+	require.Equal(t, TCRUnchanged, result.Outcome)
+
+	require.NoError(t, os.WriteFile(filepath.Join(p.path, "wip.go"), []byte("x"), 0644))
+	called := false
+	_, _, err = withTCR(ctx, p, r, func() ([]byte, error) { called = true; return nil, nil })
+	require.ErrorContains(t, err, "uncommitted changes")
+	require.False(t, called)
+	require.FileExists(t, filepath.Join(p.path, "wip.go"))
+}
+
+func TestWithTCR_commit(t *testing.T) {
+	ctx := context.Background()
+	p := tcrTestProject(t)
+	r := &ReviewRecord{ID: "r1", Comments: []FormattedComment{{File: "main.go", Content: "Fix it"}}}
+	write := func() ([]byte, error) { return nil, os.WriteFile(filepath.Join(p.path, "fixed.go"), []byte("x"), 0644) }
+
+	// Without a git identity the commit is made as tcr.
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("EMAIL", "")
+	gitOutput(t, p.path, "config", "--unset", "user.email")
+	gitOutput(t, p.path, "config", "--unset", "user.name")
+	_, result, err := withTCR(ctx, p, r, write)
+	require.NoError(t, err)
+	require.Equal(t, TCRCommitted, result.Outcome)
+	require.Equal(t, "tcr <tcr@localhost>", gitOutput(t, p.path, "log", "-1", "--format=%an <%ae>"))
+
+	// A failing commit reverts the changes.
+	base := result.Commit
+	hook := filepath.Join(p.path, ".git", "hooks", "pre-commit")
+	require.NoError(t, os.WriteFile(hook, []byte("#!/bin/sh\necho rejected\nexit 1\n"), 0755))
+	_, result, err = withTCR(ctx, p, r, func() ([]byte, error) {
+		return nil, os.WriteFile(filepath.Join(p.path, "more.go"), []byte("x"), 0644)
+	})
+	require.ErrorContains(t, err, "rejected")
+	require.Equal(t, TCRReverted, result.Outcome)
+	require.Equal(t, "commit failed, reverted to "+base[:7], result.Summary())
+	require.NoFileExists(t, filepath.Join(p.path, "more.go"))
+	require.Equal(t, base, gitOutput(t, p.path, "rev-parse", "HEAD"))
+}
+
+func TestTail(t *testing.T) {
+	require.Equal(t, "b\nc", tail("a\nb\nc\n", 2))
+	require.Equal(t, "a\nb", tail("a\nb\n", 5))
+	require.Equal(t, "a\nb", tail("a\nb", 0))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// defaultPromptTemplate is the built-in layout of review prompts. Custom
+// templates configured via review.template receive the same data: the
+// *FormattedReview with its sorted Comments, Files, UnreviewedFiles, Notes,
+// Scope and ShortCommit.
+//
+//go:embed prompt.tmpl
+var defaultPromptTemplate string
+
+var promptFuncs = template.FuncMap{
+	"inc":   func(i int) int { return i + 1 },
+	"upper": strings.ToUpper,
+	"fence": codeFence,
+}
+
+var defaultPrompt = template.Must(parsePromptTemplate("prompt", defaultPromptTemplate))
+
+// tuicrExportTemplate is the layout of the Markdown export written by
+// `tuicr --stdout`, as read by ParseReviewMarkdown.
+//
+//go:embed tuicr.tmpl
+var tuicrExportTemplate string
+
+var tuicrExport = template.Must(parsePromptTemplate("tuicr", tuicrExportTemplate))
+
+func parsePromptTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(promptFuncs).Parse(text)
+}
+
+// loadPromptTemplate reads a prompt template file. Relative paths are
+// resolved against the tcr config directory.
+func loadPromptTemplate(path string) (*template.Template, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(configDir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read prompt template: %w", err)
+	}
+	tmpl, err := parsePromptTemplate(filepath.Base(path), string(data))
+	if err != nil {
+		return nil, fmt.Errorf("parse prompt template %s: %w", path, err)
+	}
+	return tmpl, nil
+}
+
+// promptTemplate returns the template configured for the project, falling
+// back to the global template and then to the built-in one.
+func promptTemplate(p *Project) (*template.Template, error) {
+	rc := p.Config().Review
+	path := rc.Template
+	if override, ok := rc.ProjectTemplates[p.fullName()]; ok {
+		path = override
+	}
+	if path == "" {
+		return defaultPrompt, nil
+	}
+	return loadPromptTemplate(path)
+}
+
+// promptFormatter renders the review through a text/template.
+type promptFormatter struct{ tmpl *template.Template }
+
+func (f promptFormatter) Format(w io.Writer, review *FormattedReview) error {
+	slices.SortFunc(review.Comments, compareFormattedComment)
+	return f.tmpl.Execute(w, review)
+}
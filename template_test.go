@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptTemplate_projectOverride(t *testing.T) {
+	origCfg, origDir := cfg, configDir
+	t.Cleanup(func() { cfg, configDir = origCfg, origDir })
+
+	configDir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "global.tmpl"), []byte("global {{.ShortCommit}}"), 0644))
+	custom := `{{.ShortCommit}} {{len .Files}} files{{range .Comments}}
+- {{upper .Type}} {{.File}}:{{.Line}} {{.Content}}{{end}}
+notes: {{.Notes}}`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "custom.tmpl"), []byte(custom), 0644))
+	cfg.Review.Template = "global.tmpl"
+	cfg.Review.ProjectTemplates = map[string]string{"o/custom": "custom.tmpl"}
+
+	review, err := LoadReview("testdata/projector_388e9be_20260127_152442.json")
+	require.NoError(t, err)
+	review.Notes = "be brief"
+
+	f, err := projectFormatter(&Project{owner: "o", repo: "other"})
+	require.NoError(t, err)
+	out, err := formatReview(f, review)
+	require.NoError(t, err)
+	require.Equal(t, "global 388e9be", out)
+
+	f, err = projectFormatter(&Project{owner: "o", repo: "custom"})
+	require.NoError(t, err)
+	out, err = formatReview(f, review)
+	require.NoError(t, err)
+	require.Equal(t, "388e9be 1 files\n- SUGGESTION main.go:0 Make it smaller\n- NOTE main.go:18 ok\n- PRAISE main.go:18 Not ok\nnotes: be brief", out)
+}
+
+func TestPromptTemplate_invalid(t *testing.T) {
+	origCfg, origDir := cfg, configDir
+	t.Cleanup(func() { cfg, configDir = origCfg, origDir })
+
+	configDir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "bad.tmpl"), []byte("{{.Nope"), 0644))
+	cfg.Review.Template = "bad.tmpl"
+
+	_, err := projectFormatter(&Project{owner: "o", repo: "r"})
+	require.Error(t, err)
+
+	cfg.Review.Format = "json"
+	f, err := projectFormatter(&Project{owner: "o", repo: "r"})
+	require.NoError(t, err)
+	require.Equal(t, jsonFormatter{}, f)
+}
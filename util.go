@@ -5,9 +5,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"os/exec"
+	"time"
 )
 
+// shortSha abbreviates a commit SHA for display.
+func shortSha(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
 func command(ctx context.Context, dir, cmdline string, args ...string) *exec.Cmd {
 	cmd := exec.CommandContext(ctx, cmdline, args...)
 	if dir != "" {
@@ -22,6 +32,20 @@ func execute(ctx context.Context, dir, cmdline string, args ...string) ([]byte,
 	return output, err
 }
 
+// waitDelay bounds how long a canceled command may keep its output open,
+// e.g. through child processes that were not killed with it.
+const waitDelay = 2 * time.Second
+
+// executeEnv is like execute with env added to the environment of the command.
+func executeEnv(ctx context.Context, dir string, env []string, cmdline string, args ...string) ([]byte, error) {
+	cmd := command(ctx, dir, cmdline, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.WaitDelay = waitDelay
+	return cmd.CombinedOutput()
+}
+
 func cleanOutputJSON(b []byte) ([]byte, error) {
 	output := bytes.TrimRight(b, " \t\n\r")
 	idx := bytes.IndexByte(output, '{')